@@ -1,8 +1,106 @@
 package notification
 
+import (
+	"context"
+	"time"
+)
+
 // PublisherPort defines the interface for publishing notifications
 type PublisherPort interface {
 	PublishNotification(clientID string, title string, message string, notificationType NotificationType, source string) error
 	PublishCustomNotification(clientID string, notification *Notification) error
 	Close() error
 }
+
+// ChannelAdapter delivers notifications over a single channel (email, webhook, push, ...)
+type ChannelAdapter interface {
+	Channel() Channel
+	Deliver(ctx context.Context, notification *Notification) error
+}
+
+// PreferencesPort loads the notification preferences of an organization
+type PreferencesPort interface {
+	GetPreferences(ctx context.Context, orgID string) (*OrganizationNotificationPreferences, error)
+}
+
+// DeliveryRecorder records the per-channel outcome of a delivery attempt
+type DeliveryRecorder interface {
+	RecordDelivery(ctx context.Context, outcome DeliveryOutcome) error
+}
+
+// StatusReporterPort publishes delivery status events for notifications
+type StatusReporterPort interface {
+	ReportStatus(event *StatusEvent) error
+}
+
+// StatusStorePort persists delivery status events and answers timeline queries
+type StatusStorePort interface {
+	AppendStatus(ctx context.Context, event *StatusEvent) error
+	GetTimeline(ctx context.Context, notificationID string) ([]StatusEvent, error)
+}
+
+// TemplateRendererPort renders notification content from a registered template
+type TemplateRendererPort interface {
+	Render(templateID string, data any) (*RenderedContent, error)
+	RenderLocale(templateID, locale string, data any) (*RenderedContent, error)
+}
+
+// UserDirectoryPort looks up recipient attributes needed at render time
+type UserDirectoryPort interface {
+	// GetUserLocale returns the preferred locale of a user, or "" when unknown
+	GetUserLocale(ctx context.Context, userID string) (string, error)
+}
+
+// ActionHandler handles a user response to an actionable notification. Returning an
+// error causes the response to be redelivered.
+type ActionHandler func(ctx context.Context, response *ActionResponse) error
+
+// AudienceResolverPort expands an audience into its current recipients
+type AudienceResolverPort interface {
+	Resolve(ctx context.Context, audience Audience) ([]Recipient, error)
+}
+
+// TopicStorePort persists which users follow which topics
+type TopicStorePort interface {
+	Follow(ctx context.Context, subscription TopicSubscription) error
+	Unfollow(ctx context.Context, topic, userID string) error
+	// Mute silences a subscription until the given time; a zero time mutes it indefinitely
+	Mute(ctx context.Context, topic, userID string, until time.Time) error
+	Unmute(ctx context.Context, topic, userID string) error
+	Followers(ctx context.Context, topic string) ([]TopicSubscription, error)
+	Subscriptions(ctx context.Context, userID string) ([]TopicSubscription, error)
+}
+
+// UserPreferencesPort decides whether a user wants to receive a notification
+type UserPreferencesPort interface {
+	Allows(ctx context.Context, userID string, notification *Notification) (bool, error)
+}
+
+// TenantRegistryPort knows the tenants and which clients belong to each of them
+type TenantRegistryPort interface {
+	GetTenant(ctx context.Context, tenantID string) (*Tenant, error)
+	// OwnsClient reports whether a client ID belongs to a tenant
+	OwnsClient(ctx context.Context, tenantID, clientID string) (bool, error)
+}
+
+// AuthorizerPort decides whether a notification may be published. Publishers pass the
+// principal they are bound to in ctx (see PrincipalFromContext), and mark organization
+// broadcasts there too (see OrgBroadcastFromContext). Implementations return a
+// PermissionDenied error for notifications the publisher is not allowed to send.
+type AuthorizerPort interface {
+	Authorize(ctx context.Context, notification *Notification) error
+}
+
+// HealthChecker reports the health of an adapter, e.g. for /healthz endpoints.
+// Implementations honour the deadline of ctx.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) HealthReport
+}
+
+// HealthCheckFunc adapts a function to HealthChecker
+type HealthCheckFunc func(ctx context.Context) HealthReport
+
+// CheckHealth calls f(ctx)
+func (f HealthCheckFunc) CheckHealth(ctx context.Context) HealthReport {
+	return f(ctx)
+}
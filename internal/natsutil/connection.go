@@ -1,6 +1,10 @@
 package natsutil
 
 import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
 	"time"
 
 	notification "github.com/MyWeHub/notification-sdk"
@@ -20,23 +24,43 @@ func DefaultConnectOptions() []nats.Option {
 
 // ConnectWithRetry connects to NATS with retry logic
 func ConnectWithRetry(url string, maxRetries int) (*nats.Conn, error) {
-	opts := DefaultConnectOptions()
+	return ConnectWithRetryOptions(url, maxRetries, DefaultConnectOptions()...)
+}
 
-	var lastErr error
-	for i := 0; i < maxRetries; i++ {
-		nc, err := nats.Connect(url, opts...)
-		if err == nil {
-			return nc, nil
-		}
+// ConnectWithRetryOptions connects to NATS with the given options, making up to
+// maxRetries attempts with the default backoff
+func ConnectWithRetryOptions(url string, maxRetries int, opts ...nats.Option) (*nats.Conn, error) {
+	policy := DefaultRetryPolicy()
+	policy.MaxAttempts = maxRetries
+	return ConnectWithPolicy(context.Background(), url, policy, opts...)
+}
 
-		lastErr = err
-		if i < maxRetries-1 {
-			time.Sleep(time.Duration(i+1) * time.Second)
+// ConnectWithPolicy connects to NATS, retrying failed attempts according to policy until
+// ctx is done. Authentication failures are not retried. The error of a failed connect
+// lists every attempt.
+func ConnectWithPolicy(ctx context.Context, url string, policy RetryPolicy, opts ...nats.Option) (*nats.Conn, error) {
+	var nc *nats.Conn
+	err := policy.Retry(ctx, func(context.Context) error {
+		var err error
+		nc, err = nats.Connect(url, opts...)
+		if errors.Is(err, nats.ErrAuthorization) {
+			// Retrying with the same credentials cannot succeed
+			return Permanent(notification.NewError(notification.Unauthorized, "failed to connect to NATS: "+err.Error()))
 		}
-	}
+		return err
+	})
 
-	err := notification.NewError(notification.Internal, "failed to connect to NATS after retries: "+lastErr.Error())
-	return nil, err
+	var retryErr *RetryError
+	switch {
+	case err == nil:
+		return nc, nil
+	case !errors.As(err, &retryErr):
+		return nil, err
+	case len(retryErr.Attempts) == 1:
+		return nil, notification.NewError(notification.Internal, "failed to connect to NATS: "+retryErr.Attempts[0].Error())
+	default:
+		return nil, notification.NewError(notification.Internal, "failed to connect to NATS after retries: "+err.Error())
+	}
 }
 
 // ConnectWithCustomOptions connects to NATS with custom options
@@ -58,3 +82,36 @@ func CreateJetStreamContext(nc *nats.Conn) (nats.JetStreamContext, error) {
 	}
 	return js, nil
 }
+
+// EnsureStream creates a JetStream stream unless a stream with that name already exists.
+// An existing stream must capture the same subjects, otherwise it belongs to something else.
+func EnsureStream(js nats.JetStreamContext, config *nats.StreamConfig) error {
+	info, err := js.StreamInfo(config.Name)
+	if err == nil {
+		if !sameSubjects(info.Config.Subjects, config.Subjects) {
+			return notification.NewError(notification.AlreadyExists, "stream "+config.Name+" exists with subjects "+strings.Join(info.Config.Subjects, ", ")+" instead of "+strings.Join(config.Subjects, ", "))
+		}
+		return nil
+	}
+	if err != nats.ErrStreamNotFound {
+		errWrap := notification.NewError(notification.Internal, "failed to look up stream "+config.Name+": "+err.Error())
+		return errWrap
+	}
+
+	if _, err := js.AddStream(config); err != nil {
+		errWrap := notification.NewError(notification.Internal, "failed to create stream "+config.Name+": "+err.Error())
+		return errWrap
+	}
+	return nil
+}
+
+// sameSubjects reports whether two subject lists hold the same subjects in any order
+func sameSubjects(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
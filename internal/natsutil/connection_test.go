@@ -4,8 +4,11 @@ import (
 	"testing"
 	"time"
 
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats-server/v2/server"
 	"github.com/nats-io/nats.go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDefaultConnectOptions(t *testing.T) {
@@ -32,3 +35,20 @@ func TestConnectWithCustomOptions(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, nc)
 }
+
+func TestEnsureStream(t *testing.T) {
+	s := runServer(t, &server.Options{JetStream: true, StoreDir: t.TempDir()})
+	nc, err := nats.Connect(s.ClientURL())
+	require.NoError(t, err)
+	defer nc.Close()
+	js, err := CreateJetStreamContext(nc)
+	require.NoError(t, err)
+
+	config := &nats.StreamConfig{Name: "ENSURE", Subjects: []string{"ensure.a.>", "ensure.b.>"}, Storage: nats.MemoryStorage}
+	require.NoError(t, EnsureStream(js, config))
+	require.NoError(t, EnsureStream(js, &nats.StreamConfig{Name: "ENSURE", Subjects: []string{"ensure.b.>", "ensure.a.>"}}))
+
+	err = EnsureStream(js, &nats.StreamConfig{Name: "ENSURE", Subjects: []string{"other.>"}})
+	require.Error(t, err)
+	assert.EqualValues(t, notification.AlreadyExists, err.(*notification.Error).Code)
+}
@@ -0,0 +1,119 @@
+package natsutil
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+// tokenEscape introduces an escaped byte in an encoded subject token
+const tokenEscape = '%'
+
+const upperHex = "0123456789ABCDEF"
+
+// isTokenSafe reports whether a rune is kept as-is in encoded subject tokens: printable
+// characters other than the escape character and the ones NATS gives a meaning to.
+// Earlier versions published these unchanged, so clients whose IDs only use them keep
+// their subjects.
+func isTokenSafe(r rune) bool {
+	switch {
+	case r == tokenEscape, r == '.', r == '*', r == '>', r == utf8.RuneError:
+		return false
+	case r < utf8.RuneSelf:
+		return '!' <= r && r <= '~'
+	default:
+		return !unicode.IsSpace(r) && !unicode.IsControl(r)
+	}
+}
+
+// EncodeSubjectToken encodes a value as a single NATS subject token. Whitespace, control
+// characters, '.', '*', '>', '%' and invalid UTF-8 are written as %XX with two uppercase
+// hex digits per byte, so distinct values always yield distinct tokens and
+// DecodeSubjectToken recovers the original. A value of exactly EmptyToken is escaped too.
+func EncodeSubjectToken(value string) string {
+	if value == EmptyToken {
+		return escapeEmptyToken
+	}
+
+	var b []byte
+	for i := 0; i < len(value); {
+		r, size := utf8.DecodeRuneInString(value[i:])
+		if isTokenSafe(r) {
+			b = append(b, value[i:i+size]...)
+		} else {
+			for j := i; j < i+size; j++ {
+				b = escapeByte(b, value[j])
+			}
+		}
+		i += size
+	}
+	return string(b)
+}
+
+func escapeByte(b []byte, c byte) []byte {
+	return append(b, tokenEscape, upperHex[c>>4], upperHex[c&0x0F])
+}
+
+// DecodeSubjectToken reverses EncodeSubjectToken. Malformed tokens, such as lowercase or
+// truncated escapes, escaped printable ASCII or unescaped unsafe characters, are rejected.
+func DecodeSubjectToken(token string) (string, error) {
+	if token == EmptyToken {
+		return "", invalidToken(token)
+	}
+
+	b := make([]byte, 0, len(token))
+	for i := 0; i < len(token); {
+		if token[i] != tokenEscape {
+			r, size := utf8.DecodeRuneInString(token[i:])
+			if !isTokenSafe(r) {
+				return "", invalidToken(token)
+			}
+			b = append(b, token[i:i+size]...)
+			i += size
+			continue
+		}
+
+		if i+2 >= len(token) {
+			return "", invalidToken(token)
+		}
+		hi := strings.IndexByte(upperHex, token[i+1])
+		lo := strings.IndexByte(upperHex, token[i+2])
+		if hi < 0 || lo < 0 {
+			return "", invalidToken(token)
+		}
+		decoded := byte(hi<<4 | lo)
+		if decoded < utf8.RuneSelf && isTokenSafe(rune(decoded)) && token != escapeEmptyToken {
+			return "", invalidToken(token)
+		}
+		b = append(b, decoded)
+		i += 3
+	}
+	return string(b), nil
+}
+
+// escapeEmptyToken is the encoding of a value equal to EmptyToken
+var escapeEmptyToken = string(escapeByte(nil, EmptyToken[0]))
+
+func invalidToken(token string) error {
+	return notification.NewError(notification.InvalidArguments, "invalid encoded subject token: "+token)
+}
+
+// LegacySubject converts a subject whose tokens after prefix were built with
+// EncodeSubjectToken into the subject the lossy SanitizeForSubject encoding produced.
+// Tokens that are not valid encodings, such as EmptyToken, are kept unchanged.
+func LegacySubject(prefix, subject string) string {
+	rest, ok := strings.CutPrefix(subject, prefix+".")
+	if !ok {
+		return subject
+	}
+
+	tokens := strings.Split(rest, ".")
+	for i, token := range tokens {
+		if decoded, err := DecodeSubjectToken(token); err == nil {
+			tokens[i] = SanitizeForSubject(decoded)
+		}
+	}
+	return prefix + "." + strings.Join(tokens, ".")
+}
@@ -0,0 +1,61 @@
+package natsutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeSubjectToken(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"client-1", "client-1"},
+		{"a.b", "a%2Eb"},
+		{"a_b", "a_b"},
+		{"a b", "a%20b"},
+		{"*>", "%2A%3E"},
+		{"100%", "100%25"},
+		{"_", "%5F"},
+		{"user@example.com", "user@example%2Ecom"},
+		{"tab\there", "tab%09here"},
+		{"café", "café"},
+		{"nbsp\u00a0", "nbsp%C2%A0"},
+		{"bad\xff", "bad%FF"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			encoded := EncodeSubjectToken(tt.value)
+			assert.Equal(t, tt.want, encoded)
+
+			decoded, err := DecodeSubjectToken(encoded)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.value, decoded)
+		})
+	}
+
+	assert.NotEqual(t, EncodeSubjectToken("a.b"), EncodeSubjectToken("a_b"))
+	assert.NotEqual(t, EmptyToken, EncodeSubjectToken("_"))
+
+	// Client IDs that were valid tokens keep the subjects earlier versions published on
+	for _, clientID := range []string{"client_1", "user@example", "café", "a_2Eb"} {
+		assert.Equal(t, SanitizeForSubject(clientID), EncodeSubjectToken(clientID), clientID)
+	}
+}
+
+func TestDecodeSubjectTokenInvalid(t *testing.T) {
+	for _, token := range []string{"_", "a%2", "a%2e", "a%ZZ", "a%41", "a%5F", "a b", "a.b", "a%", "bad\xff"} {
+		_, err := DecodeSubjectToken(token)
+		assert.Error(t, err, token)
+	}
+}
+
+func TestLegacySubject(t *testing.T) {
+	assert.Equal(t, "notifications.a_b", LegacySubject("notifications", BuildSubject("notifications", "a.b")))
+	assert.Equal(t, "notifications.client-1", LegacySubject("notifications", BuildSubject("notifications", "client-1")))
+	assert.Equal(t, "notifications.broadcast.org.acme_inc", LegacySubject("notifications", BuildOrgBroadcastSubject("notifications", "acme inc")))
+	assert.Equal(t, "notifications.c._.error.user_1", LegacySubject("notifications", "notifications.c._.error.user%2E1"))
+}
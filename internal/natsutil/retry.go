@@ -0,0 +1,166 @@
+package natsutil
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+// RetryPolicy controls how failed operations are retried: up to MaxAttempts attempts,
+// waiting InitialBackoff after the first failure and Multiplier times longer after each
+// following one, capped at MaxBackoff. Every wait is randomized by up to ±Jitter of its
+// length, and no retry starts once MaxElapsed has passed.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Jitter is a fraction between 0 and 1
+	Jitter float64
+	// MaxElapsed bounds the total time spent retrying; zero means no bound
+	MaxElapsed time.Duration
+}
+
+// DefaultRetryPolicy returns the policy used for connecting: 3 attempts, backing off
+// from 1s to at most 30s with 20% jitter
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Second,
+		MaxBackoff:     30 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// NoRetry is a policy making a single attempt
+func NoRetry() RetryPolicy {
+	return RetryPolicy{MaxAttempts: 1}
+}
+
+// Validate checks that the policy is usable
+func (p RetryPolicy) Validate() error {
+	switch {
+	case p.MaxAttempts < 1:
+		return notification.NewError(notification.InvalidArguments, "retry policy needs at least 1 attempt")
+	case p.InitialBackoff < 0 || p.MaxBackoff < 0 || p.MaxElapsed < 0:
+		return notification.NewError(notification.InvalidArguments, "retry policy durations cannot be negative")
+	case p.Multiplier != 0 && p.Multiplier < 1:
+		return notification.NewError(notification.InvalidArguments, "retry policy multiplier must be at least 1")
+	case p.Jitter < 0 || p.Jitter > 1:
+		return notification.NewError(notification.InvalidArguments, "retry policy jitter must be between 0 and 1")
+	}
+	return nil
+}
+
+// Backoff returns the wait after the given failed attempt, counting from 1, before jitter
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier == 0 {
+		multiplier = 1
+	}
+
+	backoff := float64(p.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		backoff *= multiplier
+		if p.MaxBackoff > 0 && backoff >= float64(p.MaxBackoff) {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+	return time.Duration(backoff)
+}
+
+func (p RetryPolicy) jittered(attempt int) time.Duration {
+	backoff := p.Backoff(attempt)
+	if p.Jitter == 0 || backoff == 0 {
+		return backoff
+	}
+	delta := (rand.Float64()*2 - 1) * p.Jitter * float64(backoff)
+	return backoff + time.Duration(delta)
+}
+
+// RetryError reports the error of every attempt of a retried operation
+type RetryError struct {
+	Attempts []error
+}
+
+// Error lists the attempt errors in order
+func (e *RetryError) Error() string {
+	if len(e.Attempts) == 1 {
+		return e.Attempts[0].Error()
+	}
+
+	var b strings.Builder
+	b.WriteString(strconv.Itoa(len(e.Attempts)) + " attempts failed")
+	for i, err := range e.Attempts {
+		b.WriteString("; attempt " + strconv.Itoa(i+1) + ": " + err.Error())
+	}
+	return b.String()
+}
+
+// Unwrap exposes the attempt errors to errors.Is and errors.As
+func (e *RetryError) Unwrap() []error {
+	return e.Attempts
+}
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks an error that retrying cannot fix; Retry returns it immediately
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// Retry runs op until it succeeds, returns a Permanent error, the policy is exhausted or
+// ctx is done. Permanent errors are returned unwrapped; otherwise the result is a
+// *RetryError holding every attempt error, ending with the context error when cancelled.
+func (p RetryPolicy) Retry(ctx context.Context, op func(ctx context.Context) error) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	var attempts []error
+	for attempt := 1; ; attempt++ {
+		err := op(ctx)
+		if err == nil {
+			return nil
+		}
+
+		var permanent permanentError
+		if errors.As(err, &permanent) {
+			return permanent.err
+		}
+		attempts = append(attempts, err)
+
+		if attempt >= p.MaxAttempts {
+			return &RetryError{Attempts: attempts}
+		}
+
+		wait := p.jittered(attempt)
+		if p.MaxElapsed > 0 && time.Since(start)+wait > p.MaxElapsed {
+			return &RetryError{Attempts: attempts}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &RetryError{Attempts: append(attempts, ctx.Err())}
+		case <-timer.C:
+		}
+	}
+}
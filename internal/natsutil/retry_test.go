@@ -0,0 +1,115 @@
+package natsutil
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 10, InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+
+	assert.Equal(t, 100*time.Millisecond, policy.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, policy.Backoff(2))
+	assert.Equal(t, 800*time.Millisecond, policy.Backoff(4))
+	assert.Equal(t, time.Second, policy.Backoff(5))
+	assert.Equal(t, time.Second, policy.Backoff(50))
+
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		wait := policy.jittered(1)
+		assert.GreaterOrEqual(t, wait, 50*time.Millisecond)
+		assert.LessOrEqual(t, wait, 150*time.Millisecond)
+	}
+}
+
+func TestRetryPolicyValidate(t *testing.T) {
+	assert.NoError(t, DefaultRetryPolicy().Validate())
+	assert.NoError(t, NoRetry().Validate())
+	assert.Error(t, RetryPolicy{}.Validate())
+	assert.Error(t, RetryPolicy{MaxAttempts: 1, InitialBackoff: -time.Second}.Validate())
+	assert.Error(t, RetryPolicy{MaxAttempts: 1, Multiplier: 0.5}.Validate())
+	assert.Error(t, RetryPolicy{MaxAttempts: 1, Jitter: 2}.Validate())
+}
+
+func TestRetry(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, Multiplier: 2}
+	ctx := context.Background()
+
+	calls := 0
+	err := policy.Retry(ctx, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	errTimeout := errors.New("timeout")
+	calls = 0
+	err = policy.Retry(ctx, func(context.Context) error {
+		calls++
+		if calls == 2 {
+			return errTimeout
+		}
+		return errors.New("refused " + strconv.Itoa(calls))
+	})
+	var retryErr *RetryError
+	if assert.ErrorAs(t, err, &retryErr) {
+		assert.Len(t, retryErr.Attempts, 3)
+	}
+	assert.ErrorIs(t, err, errTimeout)
+	assert.Equal(t, "3 attempts failed; attempt 1: refused 1; attempt 2: timeout; attempt 3: refused 3", err.Error())
+
+	denied := notification.NewError(notification.Unauthorized, "denied")
+	calls = 0
+	err = policy.Retry(ctx, func(context.Context) error {
+		calls++
+		return Permanent(denied)
+	})
+	assert.Equal(t, denied, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRetryStops(t *testing.T) {
+	failing := func(context.Context) error { return errors.New("down") }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := RetryPolicy{MaxAttempts: 100, InitialBackoff: time.Hour}.Retry(ctx, failing)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	start = time.Now()
+	err = RetryPolicy{MaxAttempts: 100, InitialBackoff: 20 * time.Millisecond, MaxElapsed: 100 * time.Millisecond}.Retry(context.Background(), failing)
+	assert.Less(t, time.Since(start), time.Second)
+	var retryErr *RetryError
+	if assert.ErrorAs(t, err, &retryErr) {
+		assert.Less(t, len(retryErr.Attempts), 100)
+	}
+}
+
+func TestConnectWithPolicy(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 2, InitialBackoff: 10 * time.Millisecond}
+	nc, err := ConnectWithPolicy(context.Background(), "nats://127.0.0.1:1", policy)
+	assert.Nil(t, nc)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to connect to NATS after retries: 2 attempts failed; attempt 1:")
+		assert.Contains(t, err.Error(), "attempt 2:")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ConnectWithPolicy(ctx, "nats://127.0.0.1:1", RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), context.Canceled.Error())
+	}
+}
@@ -0,0 +1,183 @@
+package natsutil
+
+import (
+	"strings"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+// Subject template placeholders
+const (
+	PlaceholderPrefix = "{prefix}"
+	PlaceholderClient = "{client}"
+	PlaceholderUser   = "{user}"
+	PlaceholderType   = "{type}"
+	PlaceholderSource = "{source}"
+)
+
+// Subject templates
+const (
+	DefaultSubjectTemplate      = "{prefix}.{client}"
+	HierarchicalSubjectTemplate = "{prefix}.{client}.{user}.{type}.{source}"
+)
+
+// EmptyToken stands in for components that are empty, since NATS tokens cannot be.
+// EncodeSubjectToken escapes a value equal to it, so it never collides with an encoded value.
+const EmptyToken = "_"
+
+// SubjectParts are the components of a notification subject. Empty fields act as
+// wildcards when building subscription subjects.
+type SubjectParts struct {
+	ClientID string
+	UserID   string
+	Type     string
+	Source   string
+}
+
+// SubjectScheme builds and parses notification subjects following a template such as
+// "{prefix}.{client}.{user}.{type}.{source}"
+type SubjectScheme struct {
+	prefix string
+	tokens []string
+}
+
+// NewSubjectScheme creates a subject scheme from a template and the subject prefix.
+// The template must start with {prefix}, contain {client} and use each placeholder once;
+// other tokens are literals. The token after {prefix} must be {client} or a literal that
+// is not reserved, so notifications never share subjects with status, action or broadcast
+// events.
+func NewSubjectScheme(template, prefix string) (*SubjectScheme, error) {
+	tokens := strings.Split(template, ".")
+	if tokens[0] != PlaceholderPrefix {
+		return nil, notification.NewError(notification.InvalidArguments, "subject template must start with "+PlaceholderPrefix+": "+template)
+	}
+
+	seen := make(map[string]bool, len(tokens))
+	for _, token := range tokens {
+		if strings.HasPrefix(token, "{") {
+			switch token {
+			case PlaceholderPrefix, PlaceholderClient, PlaceholderUser, PlaceholderType, PlaceholderSource:
+			default:
+				return nil, notification.NewError(notification.InvalidArguments, "unknown subject placeholder "+token)
+			}
+			if seen[token] {
+				return nil, notification.NewError(notification.InvalidArguments, "subject placeholder "+token+" used more than once")
+			}
+			seen[token] = true
+			continue
+		}
+		if token == "" || strings.ContainsAny(token, "{}") {
+			return nil, notification.NewError(notification.InvalidArguments, "invalid literal token in subject template: "+template)
+		}
+		if token == "*" || token == ">" {
+			return nil, notification.NewError(notification.InvalidArguments, "subject template cannot contain wildcards: "+template)
+		}
+		if err := validateToken(template, "literal "+token, token); err != nil {
+			return nil, err
+		}
+	}
+	if !seen[PlaceholderClient] {
+		return nil, notification.NewError(notification.InvalidArguments, "subject template must contain "+PlaceholderClient+": "+template)
+	}
+	if len(tokens) > 1 && tokens[1] != PlaceholderClient && (strings.HasPrefix(tokens[1], "{") || IsReservedToken(tokens[1])) {
+		return nil, notification.NewError(notification.InvalidArguments, "subject template must continue with "+PlaceholderClient+" or an unreserved literal: "+template)
+	}
+
+	return &SubjectScheme{prefix: prefix, tokens: tokens}, nil
+}
+
+// Prefix returns the subject prefix of the scheme
+func (s *SubjectScheme) Prefix() string {
+	return s.prefix
+}
+
+// Build constructs the subject a notification is published on
+func (s *SubjectScheme) Build(n *notification.Notification) string {
+	return s.build(SubjectParts{
+		ClientID: n.ClientID,
+		UserID:   n.UserID,
+		Type:     n.Type.String(),
+		Source:   n.Source,
+	}, EmptyToken)
+}
+
+// Wildcard constructs a subscription subject matching every subject whose components
+// equal the non-empty fields of parts
+func (s *SubjectScheme) Wildcard(parts SubjectParts) string {
+	return s.build(parts, "*")
+}
+
+func (s *SubjectScheme) build(parts SubjectParts, empty string) string {
+	result := make([]string, len(s.tokens))
+	for i, token := range s.tokens {
+		var value string
+		switch token {
+		case PlaceholderPrefix:
+			result[i] = s.prefix
+			continue
+		case PlaceholderClient:
+			value = parts.ClientID
+		case PlaceholderUser:
+			value = parts.UserID
+		case PlaceholderType:
+			value = parts.Type
+		case PlaceholderSource:
+			value = parts.Source
+		default:
+			result[i] = token
+			continue
+		}
+
+		if value == "" {
+			result[i] = empty
+		} else {
+			result[i] = EncodeSubjectToken(value)
+		}
+	}
+	return strings.Join(result, ".")
+}
+
+// Parse splits a subject built by this scheme back into its decoded components
+func (s *SubjectScheme) Parse(subject string) (SubjectParts, error) {
+	var parts SubjectParts
+
+	rest, ok := strings.CutPrefix(subject, s.prefix+".")
+	if !ok {
+		return parts, notification.NewError(notification.InvalidArguments, "subject "+subject+" does not start with prefix "+s.prefix)
+	}
+
+	values := strings.Split(rest, ".")
+	if len(values) != len(s.tokens)-1 {
+		return parts, notification.NewError(notification.InvalidArguments, "subject "+subject+" does not match the subject template")
+	}
+
+	for i, token := range s.tokens[1:] {
+		value := values[i]
+		if value == EmptyToken {
+			value = ""
+		} else if strings.HasPrefix(token, "{") {
+			decoded, err := DecodeSubjectToken(value)
+			if err != nil {
+				return parts, err
+			}
+			value = decoded
+		}
+
+		switch token {
+		case PlaceholderClient:
+			parts.ClientID = value
+		case PlaceholderUser:
+			parts.UserID = value
+		case PlaceholderType:
+			parts.Type = value
+		case PlaceholderSource:
+			parts.Source = value
+		default:
+			if value != token {
+				return parts, notification.NewError(notification.InvalidArguments, "subject "+subject+" does not match the subject template")
+			}
+		}
+	}
+
+	return parts, nil
+}
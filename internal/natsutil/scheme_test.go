@@ -0,0 +1,89 @@
+package natsutil
+
+import (
+	"testing"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSubjectScheme(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{"default", DefaultSubjectTemplate, false},
+		{"hierarchical", HierarchicalSubjectTemplate, false},
+		{"literal token", "{prefix}.in.{client}.{type}", false},
+		{"missing prefix", "{client}.{type}", true},
+		{"prefix not first", "app.{prefix}.{client}", true},
+		{"missing client", "{prefix}.{user}", true},
+		{"unknown placeholder", "{prefix}.{client}.{org}", true},
+		{"duplicate placeholder", "{prefix}.{client}.{client}", true},
+		{"empty token", "{prefix}..{client}", true},
+		{"wildcard literal", "{prefix}.*.{client}", true},
+		{"reserved literal", "{prefix}.status.{client}", true},
+		{"placeholder before client", "{prefix}.{source}.{client}", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewSubjectScheme(tt.template, "notifications")
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestSubjectSchemeBuild(t *testing.T) {
+	n := &notification.Notification{ClientID: "client-1", UserID: "user-1", Type: notification.TypeError, Source: "billing"}
+
+	scheme, err := NewSubjectScheme(DefaultSubjectTemplate, "notifications")
+	assert.NoError(t, err)
+	assert.Equal(t, BuildSubject("notifications", "client-1"), scheme.Build(n))
+
+	scheme, err = NewSubjectScheme(HierarchicalSubjectTemplate, "notifications")
+	assert.NoError(t, err)
+	assert.Equal(t, "notifications.client-1.user-1.error.billing", scheme.Build(n))
+
+	n.UserID = ""
+	assert.Equal(t, "notifications.client-1._.error.billing", scheme.Build(n))
+
+	n.UserID = "_"
+	assert.Equal(t, "notifications.client-1.%5F.error.billing", scheme.Build(n))
+}
+
+func TestSubjectSchemeWildcard(t *testing.T) {
+	scheme, err := NewSubjectScheme(HierarchicalSubjectTemplate, "notifications")
+	assert.NoError(t, err)
+
+	assert.Equal(t, "notifications.*.*.*.*", scheme.Wildcard(SubjectParts{}))
+	assert.Equal(t, "notifications.client-1.*.error.*", scheme.Wildcard(SubjectParts{ClientID: "client-1", Type: "error"}))
+}
+
+func TestSubjectSchemeParse(t *testing.T) {
+	scheme, err := NewSubjectScheme("{prefix}.in.{client}.{user}.{type}", "notifications")
+	assert.NoError(t, err)
+
+	parts, err := scheme.Parse("notifications.in.client-1._.warning")
+	assert.NoError(t, err)
+	assert.Equal(t, SubjectParts{ClientID: "client-1", Type: "warning"}, parts)
+
+	parts, err = scheme.Parse("notifications.in.a%2Eb.user@example%2Ecom.warning")
+	assert.NoError(t, err)
+	assert.Equal(t, SubjectParts{ClientID: "a.b", UserID: "user@example.com", Type: "warning"}, parts)
+
+	for _, subject := range []string{
+		"other.in.client-1.user-1.warning",
+		"notifications.out.client-1.user-1.warning",
+		"notifications.in.client-1.warning",
+		"notifications.in.a%2eb.user-1.warning",
+	} {
+		_, err := scheme.Parse(subject)
+		assert.Error(t, err, subject)
+	}
+}
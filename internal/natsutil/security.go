@@ -0,0 +1,171 @@
+package natsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"strings"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats.go"
+)
+
+// TLSConfig configures TLS for the connection to NATS. Setting CertFile and KeyFile
+// presents a client certificate for mutual TLS.
+type TLSConfig struct {
+	// CAFile is a PEM bundle of the authorities the server certificate is verified
+	// against; empty uses the system roots
+	CAFile   string
+	CertFile string
+	KeyFile  string
+	// ServerName overrides the host name the server certificate is verified for
+	ServerName string
+	// InsecureSkipVerify disables server certificate verification; for tests only
+	InsecureSkipVerify bool
+}
+
+// SecurityConfig holds the credentials used to connect to NATS. At most one
+// authentication method (creds file, NKey seed, user/password or token) may be set;
+// TLS can be combined with any of them.
+type SecurityConfig struct {
+	// CredsFile is a decentralized auth .creds file holding a user JWT and its NKey seed
+	CredsFile string
+	// NKeySeedFile is a file holding a user NKey seed ("SU...")
+	NKeySeedFile string
+	Username     string
+	Password     string
+	Token        string
+	TLS          *TLSConfig
+}
+
+// Validate checks that the configuration is complete and its files are usable
+func (c *SecurityConfig) Validate() error {
+	_, err := c.Options()
+	return err
+}
+
+// Options converts the configuration into NATS connection options
+func (c *SecurityConfig) Options() ([]nats.Option, error) {
+	if c == nil {
+		return nil, nil
+	}
+
+	var methods []string
+	if c.CredsFile != "" {
+		methods = append(methods, "creds file")
+	}
+	if c.NKeySeedFile != "" {
+		methods = append(methods, "nkey seed")
+	}
+	if c.Username != "" || c.Password != "" {
+		methods = append(methods, "user/password")
+	}
+	if c.Token != "" {
+		methods = append(methods, "token")
+	}
+	if len(methods) > 1 {
+		return nil, securityError("only one authentication method may be configured, got " + strings.Join(methods, ", "))
+	}
+
+	var opts []nats.Option
+	switch {
+	case c.CredsFile != "":
+		data, err := readSecurityFile("creds file", c.CredsFile)
+		if err != nil {
+			return nil, err
+		}
+		if !strings.Contains(string(data), "BEGIN NATS USER JWT") || !strings.Contains(string(data), "BEGIN USER NKEY SEED") {
+			return nil, securityError("creds file " + c.CredsFile + " must contain a user JWT and a user nkey seed")
+		}
+		opts = append(opts, nats.UserCredentials(c.CredsFile))
+	case c.NKeySeedFile != "":
+		if _, err := readSecurityFile("nkey seed file", c.NKeySeedFile); err != nil {
+			return nil, err
+		}
+		opt, err := nats.NkeyOptionFromSeed(c.NKeySeedFile)
+		if err != nil {
+			return nil, securityError("nkey seed file " + c.NKeySeedFile + ": " + err.Error())
+		}
+		opts = append(opts, opt)
+	case c.Username != "" || c.Password != "":
+		if c.Username == "" || c.Password == "" {
+			return nil, securityError("username and password must be set together")
+		}
+		opts = append(opts, nats.UserInfo(c.Username, c.Password))
+	case c.Token != "":
+		opts = append(opts, nats.Token(c.Token))
+	}
+
+	if c.TLS != nil {
+		tlsConfig, err := c.TLS.build()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, nats.Secure(tlsConfig))
+	}
+
+	return opts, nil
+}
+
+func (c *TLSConfig) build() (*tls.Config, error) {
+	config := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CAFile != "" {
+		data, err := readSecurityFile("TLS CA file", c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, securityError("TLS CA file " + c.CAFile + " contains no PEM certificates")
+		}
+		config.RootCAs = pool
+	}
+
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return nil, securityError("TLS certificate and key files must be set together for mutual TLS")
+	}
+	if c.CertFile != "" {
+		if _, err := readSecurityFile("TLS certificate file", c.CertFile); err != nil {
+			return nil, err
+		}
+		if _, err := readSecurityFile("TLS key file", c.KeyFile); err != nil {
+			return nil, err
+		}
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, securityError("failed to load TLS client certificate: " + err.Error())
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+func readSecurityFile(kind, file string) ([]byte, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, securityError("cannot read " + kind + ": " + err.Error())
+	}
+	return data, nil
+}
+
+func securityError(message string) error {
+	return notification.NewError(notification.InvalidArguments, "invalid security config: "+message)
+}
+
+// ConnectSecure validates a security configuration and connects with it on top of
+// DefaultConnectOptions, retrying like ConnectWithRetry. Authentication failures are
+// not retried.
+func ConnectSecure(url string, security *SecurityConfig, maxRetries int) (*nats.Conn, error) {
+	securityOpts, err := security.Options()
+	if err != nil {
+		return nil, err
+	}
+
+	return ConnectWithRetryOptions(url, maxRetries, append(DefaultConnectOptions(), securityOpts...)...)
+}
@@ -0,0 +1,265 @@
+package natsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nkeys"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runServer starts an embedded NATS server on a random port
+func runServer(t *testing.T, opts *server.Options) *server.Server {
+	t.Helper()
+	opts.Host = "127.0.0.1"
+	opts.Port = -1
+	opts.NoLog = true
+	opts.NoSigs = true
+
+	s, err := server.NewServer(opts)
+	require.NoError(t, err)
+	go s.Start()
+	require.True(t, s.ReadyForConnections(5*time.Second), "embedded NATS server did not start")
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(file, data, 0o600))
+	return file
+}
+
+func assertConnects(t *testing.T, url string, security *SecurityConfig) {
+	t.Helper()
+	nc, err := ConnectSecure(url, security, 1)
+	require.NoError(t, err)
+	defer nc.Close()
+	assert.NoError(t, nc.Publish("security.test", []byte("ok")))
+	assert.NoError(t, nc.Flush())
+}
+
+func assertUnauthorized(t *testing.T, url string, security *SecurityConfig) {
+	t.Helper()
+	nc, err := ConnectSecure(url, security, 3)
+	if assert.Error(t, err) {
+		assert.EqualValues(t, notification.Unauthorized, err.(*notification.Error).Code)
+	}
+	assert.Nil(t, nc)
+}
+
+func TestConnectSecureToken(t *testing.T) {
+	s := runServer(t, &server.Options{Authorization: "s3cret"})
+
+	assertConnects(t, s.ClientURL(), &SecurityConfig{Token: "s3cret"})
+	assertUnauthorized(t, s.ClientURL(), &SecurityConfig{Token: "wrong"})
+}
+
+func TestConnectSecureUserPassword(t *testing.T) {
+	s := runServer(t, &server.Options{Username: "notifier", Password: "pa55"})
+
+	assertConnects(t, s.ClientURL(), &SecurityConfig{Username: "notifier", Password: "pa55"})
+	assertUnauthorized(t, s.ClientURL(), &SecurityConfig{Username: "notifier", Password: "wrong"})
+}
+
+func TestConnectSecureNKey(t *testing.T) {
+	user, err := nkeys.CreateUser()
+	require.NoError(t, err)
+	pub, err := user.PublicKey()
+	require.NoError(t, err)
+	seed, err := user.Seed()
+	require.NoError(t, err)
+
+	s := runServer(t, &server.Options{Nkeys: []*server.NkeyUser{{Nkey: pub}}})
+
+	assertConnects(t, s.ClientURL(), &SecurityConfig{NKeySeedFile: writeFile(t, "user.nk", seed)})
+
+	other, err := nkeys.CreateUser()
+	require.NoError(t, err)
+	otherSeed, err := other.Seed()
+	require.NoError(t, err)
+	assertUnauthorized(t, s.ClientURL(), &SecurityConfig{NKeySeedFile: writeFile(t, "other.nk", otherSeed)})
+}
+
+func TestConnectSecureCredsFile(t *testing.T) {
+	operator, err := nkeys.CreateOperator()
+	require.NoError(t, err)
+	operatorPub, err := operator.PublicKey()
+	require.NoError(t, err)
+	operatorClaims := jwt.NewOperatorClaims(operatorPub)
+	operatorJWT, err := operatorClaims.Encode(operator)
+	require.NoError(t, err)
+	operatorClaims, err = jwt.DecodeOperatorClaims(operatorJWT)
+	require.NoError(t, err)
+
+	account, err := nkeys.CreateAccount()
+	require.NoError(t, err)
+	accountPub, err := account.PublicKey()
+	require.NoError(t, err)
+	accountJWT, err := jwt.NewAccountClaims(accountPub).Encode(operator)
+	require.NoError(t, err)
+
+	user, err := nkeys.CreateUser()
+	require.NoError(t, err)
+	userPub, err := user.PublicKey()
+	require.NoError(t, err)
+	userSeed, err := user.Seed()
+	require.NoError(t, err)
+	userJWT, err := jwt.NewUserClaims(userPub).Encode(account)
+	require.NoError(t, err)
+	creds, err := jwt.FormatUserConfig(userJWT, userSeed)
+	require.NoError(t, err)
+
+	resolver := &server.MemAccResolver{}
+	require.NoError(t, resolver.Store(accountPub, accountJWT))
+	s := runServer(t, &server.Options{
+		TrustedOperators: []*jwt.OperatorClaims{operatorClaims},
+		AccountResolver:  resolver,
+	})
+
+	assertConnects(t, s.ClientURL(), &SecurityConfig{CredsFile: writeFile(t, "user.creds", creds)})
+}
+
+type testPKI struct {
+	caFile, serverCert, serverKey, clientCert, clientKey string
+}
+
+// newTestPKI creates a CA with a server certificate for 127.0.0.1 and a client certificate
+func newTestPKI(t *testing.T) testPKI {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	ca, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	issue := func(name string, serial int64, usage x509.ExtKeyUsage) (string, string) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+
+		certFile := writeFile(t, name+".pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+		keyFile := writeFile(t, name+"-key.pem", pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+		return certFile, keyFile
+	}
+
+	pki := testPKI{caFile: writeFile(t, "ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}))}
+	pki.serverCert, pki.serverKey = issue("server", 2, x509.ExtKeyUsageServerAuth)
+	pki.clientCert, pki.clientKey = issue("client", 3, x509.ExtKeyUsageClientAuth)
+	return pki
+}
+
+func runTLSServer(t *testing.T, pki testPKI, verify bool) *server.Server {
+	t.Helper()
+	tlsConfig, err := server.GenTLSConfig(&server.TLSConfigOpts{
+		CertFile: pki.serverCert,
+		KeyFile:  pki.serverKey,
+		CaFile:   pki.caFile,
+		Verify:   verify,
+	})
+	require.NoError(t, err)
+	return runServer(t, &server.Options{TLS: true, TLSVerify: verify, TLSConfig: tlsConfig, TLSTimeout: 2})
+}
+
+func TestConnectSecureTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	s := runTLSServer(t, pki, false)
+
+	assertConnects(t, s.ClientURL(), &SecurityConfig{TLS: &TLSConfig{CAFile: pki.caFile}})
+
+	// The server certificate is not trusted without the CA
+	_, err := ConnectSecure(s.ClientURL(), &SecurityConfig{TLS: &TLSConfig{}}, 1)
+	assert.Error(t, err)
+}
+
+func TestConnectSecureMutualTLS(t *testing.T) {
+	pki := newTestPKI(t)
+	s := runTLSServer(t, pki, true)
+
+	assertConnects(t, s.ClientURL(), &SecurityConfig{TLS: &TLSConfig{
+		CAFile:   pki.caFile,
+		CertFile: pki.clientCert,
+		KeyFile:  pki.clientKey,
+	}})
+
+	// The server requires a client certificate
+	_, err := ConnectSecure(s.ClientURL(), &SecurityConfig{TLS: &TLSConfig{CAFile: pki.caFile}}, 1)
+	assert.Error(t, err)
+}
+
+func TestSecurityConfigValidate(t *testing.T) {
+	pki := newTestPKI(t)
+	seedFile := writeFile(t, "not-a-seed.nk", []byte("not a seed"))
+	badCreds := writeFile(t, "bad.creds", []byte("just text"))
+
+	tests := []struct {
+		name    string
+		config  SecurityConfig
+		wantErr string
+	}{
+		{"empty", SecurityConfig{}, ""},
+		{"token", SecurityConfig{Token: "t"}, ""},
+		{"token and tls", SecurityConfig{Token: "t", TLS: &TLSConfig{CAFile: pki.caFile}}, ""},
+		{"two methods", SecurityConfig{Token: "t", Username: "u", Password: "p"}, "only one authentication method"},
+		{"user without password", SecurityConfig{Username: "u"}, "username and password must be set together"},
+		{"missing creds file", SecurityConfig{CredsFile: "/does/not/exist.creds"}, "cannot read creds file"},
+		{"invalid creds file", SecurityConfig{CredsFile: badCreds}, "must contain a user JWT"},
+		{"invalid seed", SecurityConfig{NKeySeedFile: seedFile}, "nkey seed file"},
+		{"missing CA", SecurityConfig{TLS: &TLSConfig{CAFile: "/does/not/exist.pem"}}, "cannot read TLS CA file"},
+		{"CA without certificates", SecurityConfig{TLS: &TLSConfig{CAFile: seedFile}}, "contains no PEM certificates"},
+		{"cert without key", SecurityConfig{TLS: &TLSConfig{CertFile: pki.clientCert}}, "must be set together"},
+		{"mismatched key", SecurityConfig{TLS: &TLSConfig{CertFile: pki.clientCert, KeyFile: pki.serverKey}}, "failed to load TLS client certificate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.EqualValues(t, notification.InvalidArguments, err.(*notification.Error).Code)
+			}
+		})
+	}
+}
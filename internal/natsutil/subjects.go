@@ -1,18 +1,59 @@
 package natsutil
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strconv"
 	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	notification "github.com/MyWeHub/notification-sdk"
 )
 
+// MaxSubjectLength keeps PUB and HPUB control lines, including a reply inbox and the
+// size fields, within the server's default 4 KiB max_control_line
+const MaxSubjectLength = 4000
+
+// Tokens naming the subject families and tenant namespaces that share the client level.
+// Client IDs cannot use them, so these subjects never parse as notifications.
+const (
+	StatusToken    = "status"
+	ActionsToken   = "actions"
+	BroadcastToken = "broadcast"
+	TenantToken    = "tenant"
+)
+
+// IsReservedToken reports whether token names a subject family other than notifications
+func IsReservedToken(token string) bool {
+	switch token {
+	case StatusToken, ActionsToken, BroadcastToken, TenantToken:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsReservedSubject reports whether subject, published under prefix, belongs to a
+// reserved subject family rather than to a client
+func IsReservedSubject(prefix, subject string) bool {
+	rest, ok := strings.CutPrefix(subject, prefix+".")
+	if !ok {
+		return false
+	}
+	token, _, _ := strings.Cut(rest, ".")
+	return IsReservedToken(token)
+}
+
 // BuildSubject constructs a NATS subject from prefix and client ID
 func BuildSubject(prefix, clientID string) string {
-	// Sanitize the client ID for use in NATS subjects
-	sanitized := SanitizeForSubject(clientID)
-	return fmt.Sprintf("%s.%s", prefix, sanitized)
+	return fmt.Sprintf("%s.%s", prefix, EncodeSubjectToken(clientID))
 }
 
-// SanitizeForSubject removes invalid characters from a string for NATS subject use
+// SanitizeForSubject removes invalid characters from a string for NATS subject use.
+// It is lossy, "a.b" and "a_b" both become "a_b", so subjects are built with
+// EncodeSubjectToken; it remains for LegacySubject.
 func SanitizeForSubject(input string) string {
 	// Replace spaces and special characters with underscores
 	result := strings.ReplaceAll(input, " ", "_")
@@ -22,16 +63,98 @@ func SanitizeForSubject(input string) string {
 	return result
 }
 
-// ValidateSubject checks if a subject is valid for NATS
-func ValidateSubject(subject string) bool {
+// BuildStatusSubject constructs the companion subject carrying status events for a notification
+func BuildStatusSubject(prefix, notificationID string) string {
+	return fmt.Sprintf("%s.%s.%s", prefix, StatusToken, EncodeSubjectToken(notificationID))
+}
+
+// BuildActionSubject constructs the subject carrying action responses for a source service
+func BuildActionSubject(prefix, source string) string {
+	return fmt.Sprintf("%s.%s.%s", prefix, ActionsToken, EncodeSubjectToken(source))
+}
+
+// ActionStreamName returns the JetStream stream name holding the action responses of a
+// prefix. The name carries a hash of the prefix, so distinct prefixes never share a
+// stream and any prefix yields a valid stream name.
+func ActionStreamName(prefix string) string {
+	sum := sha256.Sum256([]byte(prefix))
+	return "ACTIONS_" + hex.EncodeToString(sum[:12])
+}
+
+// BuildOrgBroadcastSubject constructs the subject for notifications addressed to every user of an organization
+func BuildOrgBroadcastSubject(prefix, orgID string) string {
+	return fmt.Sprintf("%s.%s.org.%s", prefix, BroadcastToken, EncodeSubjectToken(orgID))
+}
+
+// ValidatePublishSubject checks that a subject can be published on: a non-empty sequence
+// of non-empty, dot-separated tokens without whitespace, control characters or wildcards
+func ValidatePublishSubject(subject string) error {
+	return validateSubject(subject, false)
+}
+
+// ValidateSubscribeSubject checks that a subject can be subscribed to. In addition to the
+// publish rules it allows "*" tokens anywhere and a ">" token at the end.
+func ValidateSubscribeSubject(subject string) error {
+	return validateSubject(subject, true)
+}
+
+func validateSubject(subject string, wildcards bool) error {
 	if subject == "" {
-		return false
+		return notification.NewError(notification.InvalidArguments, "subject cannot be empty")
+	}
+	if len(subject) > MaxSubjectLength {
+		return invalidSubject(subject, "longer than "+strconv.Itoa(MaxSubjectLength)+" bytes")
+	}
+	if !utf8.ValidString(subject) {
+		return invalidSubject(subject, "not valid UTF-8")
 	}
 
-	// NATS subjects cannot contain spaces
-	if strings.Contains(subject, " ") {
-		return false
+	tokens := strings.Split(subject, ".")
+	for i, token := range tokens {
+		position := "token " + strconv.Itoa(i+1)
+		if token == "" {
+			switch i {
+			case 0:
+				return invalidSubject(subject, "starts with '.'")
+			case len(tokens) - 1:
+				return invalidSubject(subject, "ends with '.'")
+			default:
+				return invalidSubject(subject, position+" is empty")
+			}
+		}
+
+		if token == "*" || token == ">" {
+			if !wildcards {
+				return invalidSubject(subject, "wildcard "+token+" is not allowed in publish subjects")
+			}
+			if token == ">" && i != len(tokens)-1 {
+				return invalidSubject(subject, "wildcard > must be the last token")
+			}
+			continue
+		}
+		if err := validateToken(subject, position, token); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateToken checks the characters of a single literal token
+func validateToken(subject, position, token string) error {
+	for _, r := range token {
+		switch {
+		case r == '*' || r == '>':
+			return invalidSubject(subject, position+" contains wildcard "+string(r)+" inside a token")
+		case unicode.IsSpace(r):
+			return invalidSubject(subject, position+" contains whitespace "+strconv.QuoteRune(r))
+		case unicode.IsControl(r):
+			return invalidSubject(subject, position+" contains control character "+strconv.QuoteRune(r))
+		}
 	}
+	return nil
+}
 
-	return true
+func invalidSubject(subject, reason string) error {
+	return notification.NewError(notification.InvalidArguments, "invalid subject "+strconv.Quote(subject)+": "+reason)
 }
@@ -0,0 +1,74 @@
+package natsutil
+
+import (
+	"strings"
+	"testing"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSubject(t *testing.T) {
+	tests := []struct {
+		name      string
+		subject   string
+		publish   string
+		subscribe string
+	}{
+		{"simple", "notifications.client-1", "", ""},
+		{"single token", "notifications", "", ""},
+		{"unicode", "notifications.café", "", ""},
+		{"empty", "", "cannot be empty", "cannot be empty"},
+		{"space", "notifications.client 1", "whitespace ' '", "whitespace ' '"},
+		{"tab", "notifications.client\t1", "whitespace '\\t'", "whitespace '\\t'"},
+		{"newline", "notifications.client\n", "whitespace '\\n'", "whitespace '\\n'"},
+		{"control", "notifications.client\x00", "control character", "control character"},
+		{"empty token", "notifications..client", "token 2 is empty", "token 2 is empty"},
+		{"leading dot", ".notifications", "starts with '.'", "starts with '.'"},
+		{"trailing dot", "notifications.", "ends with '.'", "ends with '.'"},
+		{"star", "notifications.*", "not allowed in publish subjects", ""},
+		{"full wildcard", "notifications.>", "not allowed in publish subjects", ""},
+		{"full wildcard not last", "notifications.>.client", "not allowed in publish subjects", "must be the last token"},
+		{"wildcard inside token", "notifications.client*", "inside a token", "inside a token"},
+		{"invalid utf-8", "notifications.\xff", "not valid UTF-8", "not valid UTF-8"},
+		{"too long", "notifications." + strings.Repeat("a", MaxSubjectLength), "longer than", "longer than"},
+	}
+
+	check := func(t *testing.T, err error, want string) {
+		if want == "" {
+			assert.NoError(t, err)
+			return
+		}
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), want)
+			assert.EqualValues(t, notification.InvalidArguments, err.(*notification.Error).Code)
+		}
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check(t, ValidatePublishSubject(tt.subject), tt.publish)
+			check(t, ValidateSubscribeSubject(tt.subject), tt.subscribe)
+		})
+	}
+}
+
+func TestIsReservedSubject(t *testing.T) {
+	assert.True(t, IsReservedSubject("notifications", BuildStatusSubject("notifications", "n-1")))
+	assert.True(t, IsReservedSubject("notifications", BuildActionSubject("notifications", "billing")))
+	assert.True(t, IsReservedSubject("notifications", BuildOrgBroadcastSubject("notifications", "org-1")))
+	assert.False(t, IsReservedSubject("notifications", BuildSubject("notifications", "status-page")))
+	assert.False(t, IsReservedSubject("other", BuildStatusSubject("notifications", "n-1")))
+}
+
+func TestActionStreamName(t *testing.T) {
+	names := map[string]bool{}
+	for _, prefix := range []string{"a.b", "a_b", "X", "x", "tenant/a", `tenant\a`} {
+		name := ActionStreamName(prefix)
+		assert.Equal(t, name, ActionStreamName(prefix))
+		assert.NotContainsf(t, name, "/", prefix)
+		assert.NotContainsf(t, name, ".", prefix)
+		names[name] = true
+	}
+	assert.Len(t, names, 6)
+}
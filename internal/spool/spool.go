@@ -0,0 +1,391 @@
+// Package spool implements a durable FIFO of records stored in segment files on disk.
+//
+// Each record is stored as a 4-byte big-endian length, a 4-byte CRC-32C of the length
+// and payload, then the payload. A record that fails its checksum or is cut short ends
+// its segment: the rest of that segment is skipped and counted as corrupted, and reading
+// continues with the next segment. Records are removed once they have been replayed;
+// a crash during replay replays the current segment again, so delivery is at least once.
+package spool
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+// Default limits
+const (
+	DefaultMaxBytes     = 256 * 1024 * 1024
+	DefaultSegmentBytes = 8 * 1024 * 1024
+)
+
+const (
+	headerSize    = 8
+	segmentSuffix = ".seg"
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrFull is returned by Append when the record would exceed the size cap
+var ErrFull = errors.New("spool is full")
+
+// ErrClosed is returned by Append and Replay once the spool is closed
+var ErrClosed = errors.New("spool is closed")
+
+// Options configures a spool
+type Options struct {
+	// MaxBytes caps the bytes on disk; defaults to DefaultMaxBytes
+	MaxBytes int64
+	// SegmentBytes is the size at which a new segment file is started; defaults to DefaultSegmentBytes
+	SegmentBytes int64
+}
+
+type segment struct {
+	seq  uint64
+	path string
+	size int64
+}
+
+// Spool is a durable FIFO of records. It is safe for concurrent use, but only one
+// Replay may run at a time.
+type Spool struct {
+	dir  string
+	opts Options
+
+	mu        sync.Mutex
+	segments  []*segment
+	nextSeq   uint64   // sequence number of the next segment
+	writer    *os.File // appends to the last segment, nil until the first Append
+	reader    *os.File // reads the first segment
+	offset    int64    // read position in the first segment
+	size      int64
+	corrupted uint64
+	closed    bool
+}
+
+// Open opens the spool in dir, creating the directory if needed. Records left by a
+// previous process are replayed first; new records always go to a new segment, so a
+// record torn by a crash cannot hide the ones written after it.
+func Open(dir string, opts Options) (*Spool, error) {
+	if dir == "" {
+		return nil, notification.NewError(notification.InvalidArguments, "spool directory cannot be empty")
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	if opts.SegmentBytes <= 0 {
+		opts.SegmentBytes = DefaultSegmentBytes
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, notification.NewError(notification.Internal, "failed to create spool directory: "+err.Error())
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, notification.NewError(notification.Internal, "failed to read spool directory: "+err.Error())
+	}
+
+	s := &Spool{dir: dir, opts: opts}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), segmentSuffix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		seq, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, notification.NewError(notification.Internal, "failed to stat spool segment: "+err.Error())
+		}
+		s.segments = append(s.segments, &segment{seq: seq, path: filepath.Join(dir, entry.Name()), size: info.Size()})
+		s.size += info.Size()
+	}
+	sort.Slice(s.segments, func(i, j int) bool { return s.segments[i].seq < s.segments[j].seq })
+	if len(s.segments) > 0 {
+		s.nextSeq = s.last().seq + 1
+	}
+	return s, nil
+}
+
+// Append adds a record at the end of the spool
+func (s *Spool) Append(record []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	n := int64(headerSize + len(record))
+	if s.size+n > s.opts.MaxBytes {
+		return ErrFull
+	}
+
+	if s.writer == nil || (s.last().size > 0 && s.last().size+n > s.opts.SegmentBytes) {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	buf := make([]byte, n)
+	binary.BigEndian.PutUint32(buf[0:4], uint32(len(record)))
+	copy(buf[headerSize:], record)
+	crc := crc32.Update(crc32.Checksum(buf[0:4], crcTable), crcTable, record)
+	binary.BigEndian.PutUint32(buf[4:8], crc)
+
+	written, err := s.writer.Write(buf)
+	s.last().size += int64(written)
+	s.size += int64(written)
+	if err != nil {
+		// The torn record ends the segment; start a clean one for the next append
+		s.closeWriter()
+		return fmt.Errorf("failed to write spool record: %w", err)
+	}
+	return nil
+}
+
+// rotate starts a new segment for appends
+func (s *Spool) rotate() error {
+	s.closeWriter()
+
+	seq := s.nextSeq
+	s.nextSeq++
+	path := filepath.Join(s.dir, fmt.Sprintf("%020d%s", seq, segmentSuffix))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create spool segment: %w", err)
+	}
+	s.writer = f
+	s.segments = append(s.segments, &segment{seq: seq, path: path})
+	return nil
+}
+
+func (s *Spool) closeWriter() {
+	if s.writer != nil {
+		_ = s.writer.Close()
+		s.writer = nil
+	}
+}
+
+func (s *Spool) last() *segment {
+	return s.segments[len(s.segments)-1]
+}
+
+// Replay calls fn for every record in order. Records are passed in batches of up to batch
+// records from a single segment; once fn accepted a batch, sync is called and the batch is
+// removed only if sync returns nil, so fn may buffer records that sync then makes durable.
+// Replay stops at the first error, which is returned; the unsynced batch is replayed by
+// the next call. Records appended during Replay are replayed too.
+func (s *Spool) Replay(batch int, fn func(record []byte) error, sync func() error) (replayed int, err error) {
+	if batch < 1 {
+		batch = 1
+	}
+	for {
+		var (
+			n    int
+			next int64
+		)
+		for n < batch {
+			record, end, ok, err := s.peek(next, n > 0)
+			if err != nil {
+				return replayed, err
+			}
+			if !ok {
+				break
+			}
+			if err := fn(record); err != nil {
+				return replayed, err
+			}
+			next = end
+			n++
+		}
+		if n == 0 {
+			return replayed, nil
+		}
+		if err := sync(); err != nil {
+			return replayed, err
+		}
+		s.commit(next)
+		replayed += n
+	}
+}
+
+// peek reads the next record without removing it. The first record of a batch is read at
+// the replay position, skipping corrupted segment tails; while records of the batch are
+// pending, the next is read at pos, and the batch ends with the segment so the segment is
+// only deleted once every record in it was synced.
+func (s *Spool) peek(pos int64, pending bool) (record []byte, next int64, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for len(s.segments) > 0 {
+		if s.closed {
+			return nil, 0, false, ErrClosed
+		}
+		head := s.segments[0]
+		if !pending {
+			pos = s.offset
+		}
+		if pos >= head.size {
+			if pending || (len(s.segments) == 1 && s.writer != nil) {
+				// End of the batch, or caught up with the writer
+				return nil, 0, false, nil
+			}
+			if err := s.dropHead(); err != nil {
+				return nil, 0, false, err
+			}
+			continue
+		}
+
+		record, err := s.readAt(head, pos)
+		if err != nil {
+			if !errors.Is(err, errCorrupt) {
+				return nil, 0, false, err
+			}
+			if pending {
+				// The first peek of the next batch skips it
+				return nil, 0, false, nil
+			}
+			// Nothing after a corrupt record can be trusted
+			s.corrupted++
+			s.offset = head.size
+			continue
+		}
+		return record, pos + int64(headerSize+len(record)), true, nil
+	}
+	return nil, 0, false, nil
+}
+
+var errCorrupt = errors.New("corrupt spool record")
+
+func (s *Spool) readAt(head *segment, pos int64) ([]byte, error) {
+	if s.reader == nil {
+		f, err := os.Open(head.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open spool segment: %w", err)
+		}
+		s.reader = f
+	}
+
+	var header [headerSize]byte
+	if _, err := s.reader.ReadAt(header[:], pos); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errCorrupt
+		}
+		return nil, fmt.Errorf("failed to read spool segment: %w", err)
+	}
+	length := int64(binary.BigEndian.Uint32(header[0:4]))
+	if pos+headerSize+length > head.size {
+		return nil, errCorrupt
+	}
+
+	record := make([]byte, length)
+	if _, err := s.reader.ReadAt(record, pos+headerSize); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errCorrupt
+		}
+		return nil, fmt.Errorf("failed to read spool segment: %w", err)
+	}
+	crc := crc32.Update(crc32.Checksum(header[0:4], crcTable), crcTable, record)
+	if crc != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, errCorrupt
+	}
+	return record, nil
+}
+
+// commit removes the records of a batch up to next, deleting its segment once fully replayed
+func (s *Spool) commit(next int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.offset = next
+	if len(s.segments) == 1 && s.offset >= s.segments[0].size && s.writer != nil {
+		// Everything was replayed; reclaim the disk space of the active segment too
+		s.closeWriter()
+		_ = s.dropHead()
+	}
+}
+
+// dropHead deletes the first segment
+func (s *Spool) dropHead() error {
+	head := s.segments[0]
+	if s.reader != nil {
+		_ = s.reader.Close()
+		s.reader = nil
+	}
+	if err := os.Remove(head.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove spool segment: %w", err)
+	}
+	s.segments = s.segments[1:]
+	s.size -= head.size
+	s.offset = 0
+	return nil
+}
+
+// Empty reports whether every record has been replayed
+func (s *Spool) Empty() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, seg := range s.segments {
+		start := int64(0)
+		if i == 0 {
+			start = s.offset
+		}
+		if seg.size > start {
+			return false
+		}
+	}
+	return true
+}
+
+// Stats describes the contents of a spool
+type Stats struct {
+	// Bytes is the size of the spool on disk
+	Bytes int64
+	// Segments is the number of segment files
+	Segments int
+	// Corrupted counts the segment tails skipped because of corruption
+	Corrupted uint64
+}
+
+// Stats returns the current size of the spool
+func (s *Spool) Stats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Stats{Bytes: s.size, Segments: len(s.segments), Corrupted: s.corrupted}
+}
+
+// Close closes the segment files; records not yet replayed stay on disk
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.reader != nil {
+		_ = s.reader.Close()
+		s.reader = nil
+	}
+	if s.writer != nil {
+		if err := s.writer.Sync(); err != nil {
+			s.closeWriter()
+			return fmt.Errorf("failed to sync spool segment: %w", err)
+		}
+		s.closeWriter()
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package spool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func appendRecords(t *testing.T, s *Spool, from, to int) {
+	t.Helper()
+	for i := from; i < to; i++ {
+		require.NoError(t, s.Append([]byte("record-"+strconv.Itoa(i))))
+	}
+}
+
+func replayAll(t *testing.T, s *Spool) []string {
+	t.Helper()
+	var records []string
+	_, err := s.Replay(3, func(record []byte) error {
+		records = append(records, string(record))
+		return nil
+	}, func() error { return nil })
+	require.NoError(t, err)
+	return records
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"+segmentSuffix))
+	require.NoError(t, err)
+	return files
+}
+
+func TestReplayInOrder(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{SegmentBytes: 64})
+	require.NoError(t, err)
+	defer s.Close()
+
+	assert.True(t, s.Empty())
+	appendRecords(t, s, 0, 10)
+	assert.False(t, s.Empty())
+	assert.Greater(t, s.Stats().Segments, 1)
+
+	// The unsynced batch of a failed record is replayed again by the next call
+	calls := 0
+	replayed, err := s.Replay(2, func(record []byte) error {
+		calls++
+		if calls == 4 {
+			return errors.New("disconnected")
+		}
+		return nil
+	}, func() error { return nil })
+	assert.EqualError(t, err, "disconnected")
+	assert.Equal(t, 2, replayed)
+
+	// A batch is only removed once synced
+	replayed, err = s.Replay(2, func([]byte) error { return nil }, func() error {
+		return errors.New("flush timeout")
+	})
+	assert.EqualError(t, err, "flush timeout")
+	assert.Zero(t, replayed)
+
+	appendRecords(t, s, 10, 12)
+	records := replayAll(t, s)
+	require.Len(t, records, 10)
+	for i, record := range records {
+		assert.Equal(t, "record-"+strconv.Itoa(i+2), record)
+	}
+
+	assert.True(t, s.Empty())
+	assert.Empty(t, segmentFiles(t, dir))
+	assert.Zero(t, s.Stats().Bytes)
+
+	// Segment names are not reused after the spool empties
+	appendRecords(t, s, 12, 13)
+	assert.Equal(t, []string{"record-12"}, replayAll(t, s))
+}
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(dir, Options{SegmentBytes: 64})
+	require.NoError(t, err)
+	appendRecords(t, s, 0, 5)
+	require.NoError(t, s.Close())
+	assert.ErrorIs(t, s.Append([]byte("closed")), ErrClosed)
+	_, err = s.Replay(1, func([]byte) error { return nil }, func() error { return nil })
+	assert.ErrorIs(t, err, ErrClosed)
+
+	s, err = Open(dir, Options{SegmentBytes: 64})
+	require.NoError(t, err)
+	defer s.Close()
+	appendRecords(t, s, 5, 7)
+	assert.Equal(t, []string{"record-0", "record-1", "record-2", "record-3", "record-4", "record-5", "record-6"}, replayAll(t, s))
+}
+
+func TestMaxBytes(t *testing.T) {
+	s, err := Open(t.TempDir(), Options{MaxBytes: 40})
+	require.NoError(t, err)
+	defer s.Close()
+
+	require.NoError(t, s.Append([]byte("0123456789")))
+	require.NoError(t, s.Append([]byte("0123456789")))
+	assert.ErrorIs(t, s.Append([]byte("0123456789")), ErrFull)
+
+	replayAll(t, s)
+	assert.NoError(t, s.Append([]byte("0123456789")))
+}
+
+func TestCorruption(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(t *testing.T, path string)
+		kept    []string
+	}{
+		{"flipped byte", func(t *testing.T, path string) {
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			data[len(data)-20] ^= 0xff
+			require.NoError(t, os.WriteFile(path, data, 0o600))
+		}, []string{"record-0"}},
+		{"torn write", func(t *testing.T, path string) {
+			info, err := os.Stat(path)
+			require.NoError(t, err)
+			require.NoError(t, os.Truncate(path, info.Size()-3))
+		}, []string{"record-0", "record-1"}},
+		{"garbage length", func(t *testing.T, path string) {
+			f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+			require.NoError(t, err)
+			_, err = f.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 'x'})
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+		}, []string{"record-0", "record-1", "record-2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			s, err := Open(dir, Options{})
+			require.NoError(t, err)
+			appendRecords(t, s, 0, 3)
+			require.NoError(t, s.Close())
+			files := segmentFiles(t, dir)
+			require.Len(t, files, 1)
+			tt.corrupt(t, files[0])
+
+			// The records after the corrupted segment are still replayed
+			s, err = Open(dir, Options{})
+			require.NoError(t, err)
+			defer s.Close()
+			appendRecords(t, s, 3, 5)
+
+			assert.Equal(t, append(tt.kept, "record-3", "record-4"), replayAll(t, s))
+			assert.EqualValues(t, 1, s.Stats().Corrupted)
+			assert.Empty(t, segmentFiles(t, dir))
+		})
+	}
+}
+
+func TestOpenInvalid(t *testing.T) {
+	_, err := Open("", Options{})
+	assert.Error(t, err)
+
+	file := filepath.Join(t.TempDir(), "file")
+	require.NoError(t, os.WriteFile(file, nil, 0o600))
+	_, err = Open(file, Options{})
+	assert.Error(t, err)
+}
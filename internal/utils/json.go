@@ -25,3 +25,43 @@ func UnmarshalNotification(data []byte) (*notification.Notification, error) {
 	}
 	return &n, nil
 }
+
+// MarshalStatusEvent safely marshals a status event to JSON
+func MarshalStatusEvent(e *notification.StatusEvent) ([]byte, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		errWrap := notification.NewError(notification.Internal, "failed to marshal status event: "+err.Error())
+		return nil, errWrap
+	}
+	return data, nil
+}
+
+// UnmarshalStatusEvent safely unmarshals JSON to a status event
+func UnmarshalStatusEvent(data []byte) (*notification.StatusEvent, error) {
+	var e notification.StatusEvent
+	if err := json.Unmarshal(data, &e); err != nil {
+		errWrap := notification.NewError(notification.Internal, "failed to unmarshal status event: "+err.Error())
+		return nil, errWrap
+	}
+	return &e, nil
+}
+
+// MarshalActionResponse safely marshals an action response to JSON
+func MarshalActionResponse(r *notification.ActionResponse) ([]byte, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		errWrap := notification.NewError(notification.Internal, "failed to marshal action response: "+err.Error())
+		return nil, errWrap
+	}
+	return data, nil
+}
+
+// UnmarshalActionResponse safely unmarshals JSON to an action response
+func UnmarshalActionResponse(data []byte) (*notification.ActionResponse, error) {
+	var r notification.ActionResponse
+	if err := json.Unmarshal(data, &r); err != nil {
+		errWrap := notification.NewError(notification.Internal, "failed to unmarshal action response: "+err.Error())
+		return nil, errWrap
+	}
+	return &r, nil
+}
@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalNotificationOmitsEmptyRichContent(t *testing.T) {
+	data, err := MarshalNotification(&notification.Notification{
+		ID:        "n-1",
+		ClientID:  "client-1",
+		Title:     "Title",
+		Message:   "Message",
+		CreatedAt: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		Source:    "test",
+	})
+	assert.NoError(t, err)
+
+	var fields map[string]any
+	assert.NoError(t, json.Unmarshal(data, &fields))
+	assert.ElementsMatch(t, []string{"id", "client_id", "user_id", "title", "message", "type", "read", "created_at", "source"}, keys(fields))
+}
+
+func TestUnmarshalNotificationRichContent(t *testing.T) {
+	n, err := UnmarshalNotification([]byte(`{
+		"id": "n-1",
+		"title": "Order ready",
+		"message": "**Order 1** is ready",
+		"format": "markdown",
+		"actions": [{"id": "approve", "label": "Approve", "url": "https://example.com/approve"}],
+		"deep_link": "myapp://orders/1",
+		"image_url": "https://example.com/order.png"
+	}`))
+	assert.NoError(t, err)
+	assert.Equal(t, notification.FormatMarkdown, n.Format)
+	assert.Equal(t, []notification.Action{{ID: "approve", Label: "Approve", URL: "https://example.com/approve"}}, n.Actions)
+	assert.Equal(t, "myapp://orders/1", n.DeepLink)
+	assert.Equal(t, "https://example.com/order.png", n.ImageURL)
+}
+
+func keys(m map[string]any) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
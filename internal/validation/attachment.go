@@ -0,0 +1,64 @@
+package validation
+
+import (
+	"strconv"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+// Attachment limits
+const (
+	MaxAttachments    = 10
+	MaxAttachmentSize = 25 * 1024 * 1024
+)
+
+// ValidateAttachmentSize checks that an attachment size is within MaxAttachmentSize
+func ValidateAttachmentSize(size int64) error {
+	if size < 0 {
+		err := notification.NewError(notification.InvalidArguments, "attachment size cannot be negative")
+		return err
+	}
+
+	if size > MaxAttachmentSize {
+		err := notification.NewError(notification.InvalidArguments, "attachment cannot exceed "+strconv.Itoa(MaxAttachmentSize)+" bytes")
+		return err
+	}
+
+	return nil
+}
+
+// ValidateAttachment checks if an attachment reference is valid
+func ValidateAttachment(a notification.Attachment) error {
+	if a.Name == "" {
+		err := notification.NewError(notification.InvalidArguments, "attachment name cannot be empty")
+		return err
+	}
+
+	if len(a.Name) > 255 {
+		err := notification.NewError(notification.InvalidArguments, "attachment name cannot exceed 255 characters")
+		return err
+	}
+
+	if a.Bucket == "" || a.Key == "" {
+		err := notification.NewError(notification.InvalidArguments, "attachment "+a.Name+" must reference a bucket and key")
+		return err
+	}
+
+	return ValidateAttachmentSize(a.Size)
+}
+
+// ValidateAttachments checks the attachments of a notification
+func ValidateAttachments(attachments []notification.Attachment) error {
+	if len(attachments) > MaxAttachments {
+		err := notification.NewError(notification.InvalidArguments, "notification cannot have more than "+strconv.Itoa(MaxAttachments)+" attachments")
+		return err
+	}
+
+	for _, a := range attachments {
+		if err := ValidateAttachment(a); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
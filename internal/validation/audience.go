@@ -0,0 +1,54 @@
+package validation
+
+import (
+	"strconv"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+// MaxAudienceUsers limits the size of an explicit user list audience
+const MaxAudienceUsers = 10000
+
+// ValidateAudience checks that an audience has the fields its kind requires
+func ValidateAudience(a *notification.Audience) error {
+	if a == nil {
+		err := notification.NewError(notification.InvalidArguments, "audience cannot be nil")
+		return err
+	}
+
+	switch a.Kind {
+	case notification.AudienceOrganization:
+		if a.OrgID == "" {
+			err := notification.NewError(notification.InvalidArguments, "organization audience requires an orgID")
+			return err
+		}
+		return ValidateClientID(a.OrgID)
+	case notification.AudienceRole:
+		if a.OrgID == "" || a.Role == "" {
+			err := notification.NewError(notification.InvalidArguments, "role audience requires an orgID and a role")
+			return err
+		}
+	case notification.AudienceUsers:
+		if len(a.UserIDs) == 0 {
+			err := notification.NewError(notification.InvalidArguments, "users audience requires at least one userID")
+			return err
+		}
+		if len(a.UserIDs) > MaxAudienceUsers {
+			err := notification.NewError(notification.InvalidArguments, "users audience cannot exceed "+strconv.Itoa(MaxAudienceUsers)+" users")
+			return err
+		}
+	case notification.AudienceSegment:
+		if a.Segment == "" {
+			err := notification.NewError(notification.InvalidArguments, "segment audience requires a segment name")
+			return err
+		}
+	case "":
+		err := notification.NewError(notification.InvalidArguments, "audience kind cannot be empty")
+		return err
+	default:
+		err := notification.NewError(notification.InvalidArguments, "unknown audience kind: "+string(a.Kind))
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package validation
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+// Limits for rich notification content
+const (
+	MaxActions        = 5
+	MaxActionIDLength = 64
+	MaxActionLabel    = 40
+	MaxURLLength      = 2048
+)
+
+var actionIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidateFormat checks if a content format is supported
+func ValidateFormat(format notification.ContentFormat) error {
+	switch format {
+	case "", notification.FormatPlain, notification.FormatMarkdown:
+		return nil
+	default:
+		err := notification.NewError(notification.InvalidArguments, "unsupported content format: "+string(format))
+		return err
+	}
+}
+
+// ValidateActions checks the action buttons of a notification
+func ValidateActions(actions []notification.Action) error {
+	if len(actions) > MaxActions {
+		err := notification.NewError(notification.InvalidArguments, "notification cannot have more than "+strconv.Itoa(MaxActions)+" actions")
+		return err
+	}
+
+	seen := make(map[string]bool, len(actions))
+	for _, action := range actions {
+		if action.ID == "" {
+			err := notification.NewError(notification.InvalidArguments, "action ID cannot be empty")
+			return err
+		}
+
+		if len(action.ID) > MaxActionIDLength || !actionIDPattern.MatchString(action.ID) {
+			err := notification.NewError(notification.InvalidArguments, "action ID must be up to "+strconv.Itoa(MaxActionIDLength)+" letters, digits, '-' or '_': "+action.ID)
+			return err
+		}
+
+		if seen[action.ID] {
+			err := notification.NewError(notification.InvalidArguments, "duplicate action ID: "+action.ID)
+			return err
+		}
+		seen[action.ID] = true
+
+		if action.Label == "" {
+			err := notification.NewError(notification.InvalidArguments, "action label cannot be empty")
+			return err
+		}
+
+		if len(action.Label) > MaxActionLabel {
+			err := notification.NewError(notification.InvalidArguments, "action label cannot exceed "+strconv.Itoa(MaxActionLabel)+" characters")
+			return err
+		}
+
+		if action.URL != "" {
+			if err := ValidateWebURL("action URL", action.URL); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateWebURL checks that a URL is an absolute http or https URL
+func ValidateWebURL(field, rawURL string) error {
+	u, err := parseAbsoluteURL(field, rawURL)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		err := notification.NewError(notification.InvalidArguments, field+" must use http or https")
+		return err
+	}
+
+	if u.Host == "" {
+		err := notification.NewError(notification.InvalidArguments, field+" must have a host")
+		return err
+	}
+
+	return nil
+}
+
+// ValidateDeepLink checks that a deep link is an absolute URL; custom app schemes are
+// allowed but script-capable schemes are not
+func ValidateDeepLink(rawURL string) error {
+	u, err := parseAbsoluteURL("deep link", rawURL)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "javascript", "data", "vbscript", "file":
+		err := notification.NewError(notification.InvalidArguments, "deep link scheme not allowed: "+u.Scheme)
+		return err
+	}
+
+	return nil
+}
+
+// ValidateRichContent validates the optional structured content of a notification
+func ValidateRichContent(n *notification.Notification) error {
+	if err := ValidateFormat(n.Format); err != nil {
+		return err
+	}
+
+	if err := ValidateActions(n.Actions); err != nil {
+		return err
+	}
+
+	if n.DeepLink != "" {
+		if err := ValidateDeepLink(n.DeepLink); err != nil {
+			return err
+		}
+	}
+
+	if n.ImageURL != "" {
+		if err := ValidateWebURL("image URL", n.ImageURL); err != nil {
+			return err
+		}
+	}
+
+	if n.IconURL != "" {
+		if err := ValidateWebURL("icon URL", n.IconURL); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func parseAbsoluteURL(field, rawURL string) (*url.URL, error) {
+	if len(rawURL) > MaxURLLength {
+		err := notification.NewError(notification.InvalidArguments, field+" cannot exceed "+strconv.Itoa(MaxURLLength)+" characters")
+		return nil, err
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" {
+		err := notification.NewError(notification.InvalidArguments, field+" must be an absolute URL: "+rawURL)
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// ValidateActionResponse performs validation on a user response to a notification action
+func ValidateActionResponse(r *notification.ActionResponse) error {
+	if r == nil {
+		err := notification.NewError(notification.InvalidArguments, "action response cannot be nil")
+		return err
+	}
+
+	if r.NotificationID == "" {
+		err := notification.NewError(notification.InvalidArguments, "notificationID cannot be empty")
+		return err
+	}
+
+	if r.ActionID == "" || !actionIDPattern.MatchString(r.ActionID) {
+		err := notification.NewError(notification.InvalidArguments, "invalid action ID: "+r.ActionID)
+		return err
+	}
+
+	if r.UserID == "" {
+		err := notification.NewError(notification.InvalidArguments, "userID cannot be empty")
+		return err
+	}
+
+	return ValidateSource(r.Source)
+}
@@ -3,6 +3,8 @@ package validation
 import (
 	"strings"
 
+	"github.com/MyWeHub/notification-sdk/internal/natsutil"
+
 	notification "github.com/MyWeHub/notification-sdk"
 )
 
@@ -23,6 +25,12 @@ func ValidateClientID(clientID string) error {
 		return err
 	}
 
+	// Reserved subjects share the client level
+	if natsutil.IsReservedToken(clientID) {
+		err := notification.NewError(notification.InvalidArguments, "clientID "+clientID+" is reserved")
+		return err
+	}
+
 	return nil
 }
 
@@ -94,5 +102,13 @@ func ValidateNotification(n *notification.Notification) error {
 		return err
 	}
 
+	if err := ValidateRichContent(n); err != nil {
+		return err
+	}
+
+	if err := ValidateAttachments(n.Attachments); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,35 @@
+package validation
+
+import (
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+// ValidateStatus checks if a delivery status is one of the known statuses
+func ValidateStatus(status notification.DeliveryStatus) error {
+	switch status {
+	case notification.StatusQueued, notification.StatusPublished, notification.StatusDelivered,
+		notification.StatusRead, notification.StatusFailed:
+		return nil
+	case "":
+		err := notification.NewError(notification.InvalidArguments, "status cannot be empty")
+		return err
+	default:
+		err := notification.NewError(notification.InvalidArguments, "unknown status: "+string(status))
+		return err
+	}
+}
+
+// ValidateStatusEvent performs validation on a delivery status event
+func ValidateStatusEvent(e *notification.StatusEvent) error {
+	if e == nil {
+		err := notification.NewError(notification.InvalidArguments, "status event cannot be nil")
+		return err
+	}
+
+	if e.NotificationID == "" {
+		err := notification.NewError(notification.InvalidArguments, "notificationID cannot be empty")
+		return err
+	}
+
+	return ValidateStatus(e.Status)
+}
@@ -0,0 +1,74 @@
+package validation
+
+import (
+	"strconv"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+// MaxSMSLength is the longest SMS body accepted, ten concatenated segments
+const MaxSMSLength = 1600
+
+// ValidateTemplateID checks if a template ID is valid
+func ValidateTemplateID(templateID string) error {
+	if templateID == "" {
+		err := notification.NewError(notification.InvalidArguments, "templateID cannot be empty")
+		return err
+	}
+
+	if len(templateID) > 255 {
+		err := notification.NewError(notification.InvalidArguments, "templateID cannot exceed 255 characters")
+		return err
+	}
+
+	return nil
+}
+
+// ValidateChannelContent checks if content rendered for a channel is valid
+func ValidateChannelContent(channel notification.Channel, content notification.ChannelContent) error {
+	if content.Body == "" {
+		err := notification.NewError(notification.InvalidArguments, string(channel)+" body cannot be empty")
+		return err
+	}
+
+	if len(content.Title) > 255 {
+		err := notification.NewError(notification.InvalidArguments, string(channel)+" title cannot exceed 255 characters")
+		return err
+	}
+
+	if channel == notification.ChannelSMS && len(content.Body) > MaxSMSLength {
+		err := notification.NewError(notification.InvalidArguments, "sms body cannot exceed "+strconv.Itoa(MaxSMSLength)+" characters")
+		return err
+	}
+
+	if channel != notification.ChannelEmail && content.HTML {
+		err := notification.NewError(notification.InvalidArguments, "html content is only supported for the email channel")
+		return err
+	}
+
+	return nil
+}
+
+// ValidateRenderedContent validates rendered template content like a published notification
+func ValidateRenderedContent(c *notification.RenderedContent) error {
+	if c == nil {
+		err := notification.NewError(notification.InvalidArguments, "rendered content cannot be nil")
+		return err
+	}
+
+	if err := ValidateTitle(c.Title); err != nil {
+		return err
+	}
+
+	if err := ValidateMessage(c.Message); err != nil {
+		return err
+	}
+
+	for channel, content := range c.Variants {
+		if err := ValidateChannelContent(channel, content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
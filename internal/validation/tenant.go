@@ -0,0 +1,39 @@
+package validation
+
+import (
+	"regexp"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidateTenantID checks that a tenant ID is non-empty and usable as a subject token
+func ValidateTenantID(tenantID string) error {
+	if tenantID == "" {
+		err := notification.NewError(notification.InvalidArguments, "tenantID cannot be empty")
+		return err
+	}
+
+	if len(tenantID) > 64 {
+		err := notification.NewError(notification.InvalidArguments, "tenantID cannot exceed 64 characters")
+		return err
+	}
+
+	if !tenantIDPattern.MatchString(tenantID) {
+		err := notification.NewError(notification.InvalidArguments, "tenantID may only contain letters, digits, '_' and '-': "+tenantID)
+		return err
+	}
+
+	return nil
+}
+
+// ValidateTenant performs validation on a tenant definition
+func ValidateTenant(t *notification.Tenant) error {
+	if t == nil {
+		err := notification.NewError(notification.InvalidArguments, "tenant cannot be nil")
+		return err
+	}
+
+	return ValidateTenantID(t.ID)
+}
@@ -0,0 +1,43 @@
+package validation
+
+import (
+	"regexp"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+var topicPattern = regexp.MustCompile(`^[a-z0-9_-]+:[A-Za-z0-9_.:-]+$`)
+
+// ValidateTopic checks that a topic has the form <kind>:<id>, e.g. project:123
+func ValidateTopic(topic string) error {
+	if topic == "" {
+		err := notification.NewError(notification.InvalidArguments, "topic cannot be empty")
+		return err
+	}
+
+	if len(topic) > 255 {
+		err := notification.NewError(notification.InvalidArguments, "topic cannot exceed 255 characters")
+		return err
+	}
+
+	if !topicPattern.MatchString(topic) {
+		err := notification.NewError(notification.InvalidArguments, "topic must have the form <kind>:<id>: "+topic)
+		return err
+	}
+
+	return nil
+}
+
+// ValidateTopicSubscription performs validation on a topic subscription
+func ValidateTopicSubscription(s notification.TopicSubscription) error {
+	if err := ValidateTopic(s.Topic); err != nil {
+		return err
+	}
+
+	if s.UserID == "" {
+		err := notification.NewError(notification.InvalidArguments, "userID cannot be empty")
+		return err
+	}
+
+	return ValidateClientID(s.ClientID)
+}
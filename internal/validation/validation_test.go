@@ -17,6 +17,11 @@ func TestValidateClientID(t *testing.T) {
 		{"empty client ID", "", true},
 		{"client ID with spaces", "client 123", true},
 		{"too long client ID", strings.Repeat("a", 256), true},
+		{"reserved status token", "status", true},
+		{"reserved actions token", "actions", true},
+		{"reserved broadcast token", "broadcast", true},
+		{"reserved tenant token", "tenant", true},
+		{"reserved token as part", "status-page", false},
 	}
 
 	for _, tt := range tests {
@@ -101,3 +106,207 @@ func TestValidateNotification(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateStatusEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		event   *notification.StatusEvent
+		wantErr bool
+	}{
+		{"valid event", &notification.StatusEvent{NotificationID: "n-1", Status: notification.StatusDelivered}, false},
+		{"nil event", nil, true},
+		{"missing notification ID", &notification.StatusEvent{Status: notification.StatusRead}, true},
+		{"missing status", &notification.StatusEvent{NotificationID: "n-1"}, true},
+		{"unknown status", &notification.StatusEvent{NotificationID: "n-1", Status: "bounced"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStatusEvent(tt.event)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateStatusEvent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateRichContent(t *testing.T) {
+	actions := func(n int) []notification.Action {
+		result := make([]notification.Action, n)
+		for i := range result {
+			result[i] = notification.Action{ID: "action-" + strings.Repeat("x", i), Label: "Label"}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name    string
+		n       *notification.Notification
+		wantErr bool
+	}{
+		{"no rich content", &notification.Notification{}, false},
+		{"full rich content", &notification.Notification{
+			Format:   notification.FormatMarkdown,
+			Actions:  []notification.Action{{ID: "approve", Label: "Approve", URL: "https://app.example.com/orders/1/approve"}, {ID: "view_order", Label: "View order"}},
+			DeepLink: "myapp://orders/1",
+			ImageURL: "https://cdn.example.com/order.png",
+			IconURL:  "http://cdn.example.com/icon.png",
+		}, false},
+		{"unknown format", &notification.Notification{Format: "html"}, true},
+		{"too many actions", &notification.Notification{Actions: actions(MaxActions + 1)}, true},
+		{"empty action ID", &notification.Notification{Actions: []notification.Action{{Label: "Approve"}}}, true},
+		{"invalid action ID", &notification.Notification{Actions: []notification.Action{{ID: "approve order", Label: "Approve"}}}, true},
+		{"duplicate action ID", &notification.Notification{Actions: []notification.Action{{ID: "a", Label: "A"}, {ID: "a", Label: "B"}}}, true},
+		{"empty action label", &notification.Notification{Actions: []notification.Action{{ID: "a"}}}, true},
+		{"too long action label", &notification.Notification{Actions: []notification.Action{{ID: "a", Label: strings.Repeat("a", MaxActionLabel+1)}}}, true},
+		{"relative action URL", &notification.Notification{Actions: []notification.Action{{ID: "a", Label: "A", URL: "/orders/1"}}}, true},
+		{"non-web action URL", &notification.Notification{Actions: []notification.Action{{ID: "a", Label: "A", URL: "ftp://example.com/file"}}}, true},
+		{"javascript deep link", &notification.Notification{DeepLink: "javascript:alert(1)"}, true},
+		{"relative deep link", &notification.Notification{DeepLink: "orders/1"}, true},
+		{"image without host", &notification.Notification{ImageURL: "https:///image.png"}, true},
+		{"too long icon URL", &notification.Notification{IconURL: "https://example.com/" + strings.Repeat("a", MaxURLLength)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRichContent(tt.n)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRichContent() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateActionResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *notification.ActionResponse
+		wantErr  bool
+	}{
+		{"valid response", &notification.ActionResponse{NotificationID: "n-1", ActionID: "approve", UserID: "u-1", Source: "orders"}, false},
+		{"nil response", nil, true},
+		{"missing notification ID", &notification.ActionResponse{ActionID: "approve", UserID: "u-1", Source: "orders"}, true},
+		{"invalid action ID", &notification.ActionResponse{NotificationID: "n-1", ActionID: "a.b", UserID: "u-1", Source: "orders"}, true},
+		{"missing user ID", &notification.ActionResponse{NotificationID: "n-1", ActionID: "approve", Source: "orders"}, true},
+		{"missing source", &notification.ActionResponse{NotificationID: "n-1", ActionID: "approve", UserID: "u-1"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateActionResponse(tt.response)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateActionResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAttachments(t *testing.T) {
+	valid := notification.Attachment{Name: "export.csv", Size: 1024, Bucket: "exports", Key: "k-1"}
+	tooMany := make([]notification.Attachment, MaxAttachments+1)
+	for i := range tooMany {
+		tooMany[i] = valid
+	}
+
+	tests := []struct {
+		name        string
+		attachments []notification.Attachment
+		wantErr     bool
+	}{
+		{"no attachments", nil, false},
+		{"valid attachment", []notification.Attachment{valid}, false},
+		{"too many attachments", tooMany, true},
+		{"missing name", []notification.Attachment{{Size: 1, Bucket: "exports", Key: "k"}}, true},
+		{"missing key", []notification.Attachment{{Name: "a", Size: 1, Bucket: "exports"}}, true},
+		{"too large", []notification.Attachment{{Name: "a", Size: MaxAttachmentSize + 1, Bucket: "exports", Key: "k"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAttachments(tt.attachments)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAttachments() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAudience(t *testing.T) {
+	tests := []struct {
+		name     string
+		audience *notification.Audience
+		wantErr  bool
+	}{
+		{"organization", &notification.Audience{Kind: notification.AudienceOrganization, OrgID: "org-1"}, false},
+		{"role", &notification.Audience{Kind: notification.AudienceRole, OrgID: "org-1", Role: "admin"}, false},
+		{"users", &notification.Audience{Kind: notification.AudienceUsers, UserIDs: []string{"u-1"}}, false},
+		{"segment", &notification.Audience{Kind: notification.AudienceSegment, Segment: "beta"}, false},
+		{"nil audience", nil, true},
+		{"missing kind", &notification.Audience{OrgID: "org-1"}, true},
+		{"unknown kind", &notification.Audience{Kind: "team"}, true},
+		{"organization without ID", &notification.Audience{Kind: notification.AudienceOrganization}, true},
+		{"organization ID with spaces", &notification.Audience{Kind: notification.AudienceOrganization, OrgID: "org 1"}, true},
+		{"role without role", &notification.Audience{Kind: notification.AudienceRole, OrgID: "org-1"}, true},
+		{"empty users", &notification.Audience{Kind: notification.AudienceUsers}, true},
+		{"segment without name", &notification.Audience{Kind: notification.AudienceSegment}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAudience(tt.audience)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAudience() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTopic(t *testing.T) {
+	tests := []struct {
+		name    string
+		topic   string
+		wantErr bool
+	}{
+		{"valid topic", "project:123", false},
+		{"slash in ID", "repo:org/name", true},
+		{"dotted ID", "file:report.pdf", false},
+		{"empty topic", "", true},
+		{"missing kind", ":123", true},
+		{"missing ID", "project:", true},
+		{"no separator", "project", true},
+		{"spaces", "project: 123", true},
+		{"too long", "project:" + strings.Repeat("a", 255), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTopic(tt.topic)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTopic() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateTenantID(t *testing.T) {
+	tests := []struct {
+		name     string
+		tenantID string
+		wantErr  bool
+	}{
+		{"valid tenant", "acme-corp_1", false},
+		{"empty tenant", "", true},
+		{"dot", "acme.corp", true},
+		{"space", "acme corp", true},
+		{"too long", strings.Repeat("a", 65), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTenantID(tt.tenantID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTenantID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
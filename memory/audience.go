@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+type member struct {
+	recipient notification.Recipient
+	orgID     string
+	roles     map[string]bool
+}
+
+// AudienceDirectory resolves audiences against users and segments registered in memory
+type AudienceDirectory struct {
+	mu       sync.RWMutex
+	users    map[string]*member
+	segments map[string][]string
+}
+
+// NewAudienceDirectory creates an empty audience directory
+func NewAudienceDirectory() *AudienceDirectory {
+	return &AudienceDirectory{
+		users:    make(map[string]*member),
+		segments: make(map[string][]string),
+	}
+}
+
+// AddUser registers a user of an organization with its client ID and roles
+func (d *AudienceDirectory) AddUser(userID, clientID, orgID string, roles ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	m := &member{
+		recipient: notification.Recipient{ClientID: clientID, UserID: userID},
+		orgID:     orgID,
+		roles:     make(map[string]bool, len(roles)),
+	}
+	for _, role := range roles {
+		m.roles[role] = true
+	}
+	d.users[userID] = m
+}
+
+// DefineSegment sets the users of a named segment
+func (d *AudienceDirectory) DefineSegment(name string, userIDs ...string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.segments[name] = append([]string(nil), userIDs...)
+}
+
+// Resolve expands an audience into the registered users it targets, ordered by user ID.
+// Unknown users in explicit user lists are skipped.
+func (d *AudienceDirectory) Resolve(_ context.Context, audience notification.Audience) ([]notification.Recipient, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var userIDs []string
+	switch audience.Kind {
+	case notification.AudienceOrganization, notification.AudienceRole:
+		for id, m := range d.users {
+			if m.orgID == audience.OrgID && (audience.Kind == notification.AudienceOrganization || m.roles[audience.Role]) {
+				userIDs = append(userIDs, id)
+			}
+		}
+	case notification.AudienceUsers:
+		userIDs = audience.UserIDs
+	case notification.AudienceSegment:
+		segment, ok := d.segments[audience.Segment]
+		if !ok {
+			return nil, notification.NewError(notification.NotFound, "segment "+audience.Segment+" not found")
+		}
+		userIDs = segment
+	default:
+		return nil, notification.NewError(notification.InvalidArguments, "unknown audience kind: "+string(audience.Kind))
+	}
+
+	seen := make(map[string]bool, len(userIDs))
+	recipients := make([]notification.Recipient, 0, len(userIDs))
+	for _, id := range userIDs {
+		m, ok := d.users[id]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		recipients = append(recipients, m.recipient)
+	}
+	sort.Slice(recipients, func(i, j int) bool {
+		return recipients[i].UserID < recipients[j].UserID
+	})
+
+	return recipients, nil
+}
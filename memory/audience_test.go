@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudienceDirectoryResolve(t *testing.T) {
+	directory := NewAudienceDirectory()
+	directory.AddUser("u-1", "client-1", "org-a", "admin")
+	directory.AddUser("u-2", "client-2", "org-a", "member")
+	directory.AddUser("u-3", "client-3", "org-b", "admin")
+	directory.DefineSegment("beta", "u-3", "u-1", "u-unknown")
+
+	userIDs := func(recipients []notification.Recipient) []string {
+		ids := make([]string, len(recipients))
+		for i, r := range recipients {
+			ids[i] = r.UserID
+		}
+		return ids
+	}
+
+	tests := []struct {
+		name     string
+		audience notification.Audience
+		expected []string
+	}{
+		{"organization", notification.Audience{Kind: notification.AudienceOrganization, OrgID: "org-a"}, []string{"u-1", "u-2"}},
+		{"role", notification.Audience{Kind: notification.AudienceRole, OrgID: "org-a", Role: "admin"}, []string{"u-1"}},
+		{"users", notification.Audience{Kind: notification.AudienceUsers, UserIDs: []string{"u-2", "u-3", "u-2", "u-9"}}, []string{"u-2", "u-3"}},
+		{"segment", notification.Audience{Kind: notification.AudienceSegment, Segment: "beta"}, []string{"u-1", "u-3"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recipients, err := directory.Resolve(context.Background(), tt.audience)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, userIDs(recipients))
+		})
+	}
+
+	recipients, _ := directory.Resolve(context.Background(), notification.Audience{Kind: notification.AudienceUsers, UserIDs: []string{"u-1"}})
+	assert.Equal(t, []notification.Recipient{{ClientID: "client-1", UserID: "u-1"}}, recipients)
+
+	_, err := directory.Resolve(context.Background(), notification.Audience{Kind: notification.AudienceSegment, Segment: "missing"})
+	assert.Error(t, err)
+}
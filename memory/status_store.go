@@ -0,0 +1,56 @@
+// Package memory provides in-memory implementations of the SDK ports, suitable for tests
+// and single-instance deployments.
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+// StatusStore keeps delivery status events in memory
+type StatusStore struct {
+	mu     sync.RWMutex
+	events map[string][]notification.StatusEvent
+}
+
+// NewStatusStore creates an empty in-memory status store
+func NewStatusStore() *StatusStore {
+	return &StatusStore{
+		events: make(map[string][]notification.StatusEvent),
+	}
+}
+
+// AppendStatus records a status event
+func (s *StatusStore) AppendStatus(_ context.Context, event *notification.StatusEvent) error {
+	if event == nil || event.NotificationID == "" {
+		return notification.NewError(notification.InvalidArguments, "status event must have a notificationID")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timeline := append(s.events[event.NotificationID], *event)
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].Timestamp.Before(timeline[j].Timestamp)
+	})
+	s.events[event.NotificationID] = timeline
+	return nil
+}
+
+// GetTimeline returns the status events of a notification in chronological order
+func (s *StatusStore) GetTimeline(_ context.Context, notificationID string) ([]notification.StatusEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	timeline, ok := s.events[notificationID]
+	if !ok {
+		return nil, notification.NewError(notification.NotFound, "no status events for notification "+notificationID)
+	}
+
+	result := make([]notification.StatusEvent, len(timeline))
+	copy(result, timeline)
+	return result, nil
+}
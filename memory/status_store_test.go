@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusStoreTimeline(t *testing.T) {
+	store := NewStatusStore()
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	events := []*notification.StatusEvent{
+		{NotificationID: "n-1", Status: notification.StatusDelivered, Channel: notification.ChannelInApp, Timestamp: now.Add(2 * time.Second)},
+		{NotificationID: "n-1", Status: notification.StatusPublished, Timestamp: now.Add(time.Second)},
+		{NotificationID: "n-1", Status: notification.StatusQueued, Timestamp: now},
+		{NotificationID: "n-2", Status: notification.StatusFailed, Timestamp: now},
+	}
+	for _, e := range events {
+		assert.NoError(t, store.AppendStatus(ctx, e))
+	}
+
+	timeline, err := store.GetTimeline(ctx, "n-1")
+	assert.NoError(t, err)
+	assert.Len(t, timeline, 3)
+	assert.Equal(t, notification.StatusQueued, timeline[0].Status)
+	assert.Equal(t, notification.StatusPublished, timeline[1].Status)
+	assert.Equal(t, notification.StatusDelivered, timeline[2].Status)
+}
+
+func TestStatusStoreErrors(t *testing.T) {
+	store := NewStatusStore()
+	ctx := context.Background()
+
+	assert.Error(t, store.AppendStatus(ctx, nil))
+	assert.Error(t, store.AppendStatus(ctx, &notification.StatusEvent{Status: notification.StatusQueued}))
+
+	_, err := store.GetTimeline(ctx, "missing")
+	assert.Error(t, err)
+	notifErr, ok := err.(*notification.Error)
+	assert.True(t, ok)
+	assert.Equal(t, int32(notification.NotFound), notifErr.Code)
+}
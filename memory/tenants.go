@@ -0,0 +1,77 @@
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MyWeHub/notification-sdk/internal/validation"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+// TenantRegistry is a TenantRegistryPort backed by tenants and clients registered in memory
+type TenantRegistry struct {
+	mu      sync.RWMutex
+	tenants map[string]notification.Tenant
+	clients map[string]string
+}
+
+// NewTenantRegistry creates an empty tenant registry
+func NewTenantRegistry() *TenantRegistry {
+	return &TenantRegistry{
+		tenants: make(map[string]notification.Tenant),
+		clients: make(map[string]string),
+	}
+}
+
+// AddTenant registers or replaces a tenant
+func (r *TenantRegistry) AddTenant(tenant notification.Tenant) error {
+	if err := validation.ValidateTenant(&tenant); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.tenants[tenant.ID] = tenant
+	return nil
+}
+
+// AddClient assigns a client ID to a registered tenant. A client belongs to at most one tenant.
+func (r *TenantRegistry) AddClient(tenantID, clientID string) error {
+	if err := validation.ValidateClientID(clientID); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tenants[tenantID]; !ok {
+		return notification.NewError(notification.NotFound, "tenant "+tenantID+" not found")
+	}
+	if owner, ok := r.clients[clientID]; ok && owner != tenantID {
+		return notification.NewError(notification.AlreadyExists, "client "+clientID+" already belongs to tenant "+owner)
+	}
+	r.clients[clientID] = tenantID
+	return nil
+}
+
+// GetTenant returns a registered tenant
+func (r *TenantRegistry) GetTenant(_ context.Context, tenantID string) (*notification.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tenant, ok := r.tenants[tenantID]
+	if !ok {
+		return nil, notification.NewError(notification.NotFound, "tenant "+tenantID+" not found")
+	}
+	return &tenant, nil
+}
+
+// OwnsClient reports whether a client ID was assigned to a tenant
+func (r *TenantRegistry) OwnsClient(_ context.Context, tenantID, clientID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.clients[clientID] == tenantID, nil
+}
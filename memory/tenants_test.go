@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantRegistry(t *testing.T) {
+	registry := NewTenantRegistry()
+	ctx := context.Background()
+
+	assert.NoError(t, registry.AddTenant(notification.Tenant{ID: "acme"}))
+	assert.NoError(t, registry.AddTenant(notification.Tenant{ID: "globex", SubjectPrefix: "globex.notifications"}))
+	assert.Error(t, registry.AddTenant(notification.Tenant{ID: "bad tenant"}))
+
+	assert.NoError(t, registry.AddClient("acme", "client-1"))
+	assert.NoError(t, registry.AddClient("acme", "client-1"))
+	assert.Error(t, registry.AddClient("globex", "client-1"))
+	assert.Error(t, registry.AddClient("initech", "client-2"))
+
+	tenant, err := registry.GetTenant(ctx, "globex")
+	assert.NoError(t, err)
+	assert.Equal(t, "globex.notifications", tenant.SubjectPrefix)
+	_, err = registry.GetTenant(ctx, "initech")
+	assert.Error(t, err)
+
+	owns, err := registry.OwnsClient(ctx, "acme", "client-1")
+	assert.NoError(t, err)
+	assert.True(t, owns)
+	owns, _ = registry.OwnsClient(ctx, "globex", "client-1")
+	assert.False(t, owns)
+}
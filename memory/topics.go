@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/MyWeHub/notification-sdk/internal/utils"
+	"github.com/MyWeHub/notification-sdk/internal/validation"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+// TopicStore keeps topic subscriptions in memory
+type TopicStore struct {
+	mu     sync.RWMutex
+	topics map[string]map[string]notification.TopicSubscription
+}
+
+// NewTopicStore creates an empty in-memory topic store
+func NewTopicStore() *TopicStore {
+	return &TopicStore{
+		topics: make(map[string]map[string]notification.TopicSubscription),
+	}
+}
+
+// Follow subscribes a user to a topic, replacing any existing subscription
+func (s *TopicStore) Follow(_ context.Context, subscription notification.TopicSubscription) error {
+	if err := validation.ValidateTopicSubscription(subscription); err != nil {
+		return err
+	}
+	if utils.IsZeroTime(subscription.CreatedAt) {
+		subscription.CreatedAt = utils.UTCNow()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	followers, ok := s.topics[subscription.Topic]
+	if !ok {
+		followers = make(map[string]notification.TopicSubscription)
+		s.topics[subscription.Topic] = followers
+	}
+	followers[subscription.UserID] = subscription
+	return nil
+}
+
+// Unfollow removes a user's subscription to a topic
+func (s *TopicStore) Unfollow(_ context.Context, topic, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.topics[topic][userID]; !ok {
+		return notification.NewError(notification.NotFound, "user "+userID+" does not follow "+topic)
+	}
+	delete(s.topics[topic], userID)
+	if len(s.topics[topic]) == 0 {
+		delete(s.topics, topic)
+	}
+	return nil
+}
+
+// Mute silences a subscription until the given time; a zero time mutes it indefinitely
+func (s *TopicStore) Mute(_ context.Context, topic, userID string, until time.Time) error {
+	return s.update(topic, userID, func(sub *notification.TopicSubscription) {
+		sub.Muted = true
+		sub.MutedUntil = until
+	})
+}
+
+// Unmute lifts the mute of a subscription
+func (s *TopicStore) Unmute(_ context.Context, topic, userID string) error {
+	return s.update(topic, userID, func(sub *notification.TopicSubscription) {
+		sub.Muted = false
+		sub.MutedUntil = time.Time{}
+	})
+}
+
+// Followers returns the subscriptions of a topic, ordered by user ID
+func (s *TopicStore) Followers(_ context.Context, topic string) ([]notification.TopicSubscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]notification.TopicSubscription, 0, len(s.topics[topic]))
+	for _, sub := range s.topics[topic] {
+		result = append(result, sub)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UserID < result[j].UserID
+	})
+	return result, nil
+}
+
+// Subscriptions returns the topics a user follows, ordered by topic
+func (s *TopicStore) Subscriptions(_ context.Context, userID string) ([]notification.TopicSubscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []notification.TopicSubscription
+	for _, followers := range s.topics {
+		if sub, ok := followers[userID]; ok {
+			result = append(result, sub)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Topic < result[j].Topic
+	})
+	return result, nil
+}
+
+func (s *TopicStore) update(topic, userID string, apply func(*notification.TopicSubscription)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sub, ok := s.topics[topic][userID]
+	if !ok {
+		return notification.NewError(notification.NotFound, "user "+userID+" does not follow "+topic)
+	}
+	apply(&sub)
+	s.topics[topic][userID] = sub
+	return nil
+}
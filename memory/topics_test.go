@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopicStore(t *testing.T) {
+	store := NewTopicStore()
+	ctx := context.Background()
+
+	assert.NoError(t, store.Follow(ctx, notification.TopicSubscription{Topic: "project:123", UserID: "u-2", ClientID: "client-2"}))
+	assert.NoError(t, store.Follow(ctx, notification.TopicSubscription{Topic: "project:123", UserID: "u-1", ClientID: "client-1"}))
+	assert.NoError(t, store.Follow(ctx, notification.TopicSubscription{Topic: "invoice:9", UserID: "u-1", ClientID: "client-1"}))
+	assert.Error(t, store.Follow(ctx, notification.TopicSubscription{Topic: "project 123", UserID: "u-1", ClientID: "client-1"}))
+
+	followers, err := store.Followers(ctx, "project:123")
+	assert.NoError(t, err)
+	assert.Len(t, followers, 2)
+	assert.Equal(t, "u-1", followers[0].UserID)
+	assert.False(t, followers[0].CreatedAt.IsZero())
+
+	subscriptions, err := store.Subscriptions(ctx, "u-1")
+	assert.NoError(t, err)
+	assert.Len(t, subscriptions, 2)
+	assert.Equal(t, "invoice:9", subscriptions[0].Topic)
+
+	until := time.Now().Add(time.Hour)
+	assert.NoError(t, store.Mute(ctx, "project:123", "u-2", until))
+	followers, _ = store.Followers(ctx, "project:123")
+	assert.True(t, followers[1].IsMuted(time.Now()))
+	assert.False(t, followers[1].IsMuted(until.Add(time.Second)))
+
+	assert.NoError(t, store.Unmute(ctx, "project:123", "u-2"))
+	followers, _ = store.Followers(ctx, "project:123")
+	assert.False(t, followers[1].IsMuted(time.Now()))
+
+	assert.NoError(t, store.Unfollow(ctx, "project:123", "u-1"))
+	assert.Error(t, store.Unfollow(ctx, "project:123", "u-1"))
+	assert.Error(t, store.Mute(ctx, "project:123", "u-1", time.Time{}))
+	followers, _ = store.Followers(ctx, "project:123")
+	assert.Len(t, followers, 1)
+
+	followers, err = store.Followers(ctx, "project:unknown")
+	assert.NoError(t, err)
+	assert.Empty(t, followers)
+}
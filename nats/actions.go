@@ -0,0 +1,317 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/MyWeHub/notification-sdk/internal/natsutil"
+	"github.com/MyWeHub/notification-sdk/internal/utils"
+	"github.com/MyWeHub/notification-sdk/internal/validation"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultActionDuplicateWindow is how long the action stream remembers response IDs for deduplication
+const DefaultActionDuplicateWindow = 2 * time.Minute
+
+// DefaultActionMaxDeliver is how often a response is delivered before the router gives up on it
+const DefaultActionMaxDeliver = 10
+
+// DefaultActionRedelivery returns the backoff between deliveries of a response whose
+// handler failed: from 1s, doubling up to 1 minute
+func DefaultActionRedelivery() RetryPolicy {
+	return RetryPolicy{
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Minute,
+		Multiplier:     2,
+	}
+}
+
+// PublishActionResponse publishes a user's response to a notification action on
+// <prefix>.actions.<source>. Responses are deduplicated by JetStream on notification,
+// action and user, so a publish retried within the stream's duplicate window is stored
+// once. A response published again after the window is stored and handled again.
+func (p *Publisher) PublishActionResponse(response *notification.ActionResponse) error {
+	if err := validation.ValidateActionResponse(response); err != nil {
+		return err
+	}
+
+	if utils.IsZeroTime(response.Timestamp) {
+		response.Timestamp = p.now()
+	}
+
+	data, err := utils.MarshalActionResponse(response)
+	if err != nil {
+		return err
+	}
+
+	js, err := p.jetStream()
+	if err != nil {
+		return err
+	}
+	if err := p.begin(); err != nil {
+		return err
+	}
+	defer p.end()
+
+	subject := natsutil.BuildActionSubject(p.subjectPrefix, response.Source)
+	if err := natsutil.ValidatePublishSubject(subject); err != nil {
+		return err
+	}
+	// The message ID makes retried publishes safe
+	err = p.retry.Retry(context.Background(), func(context.Context) error {
+		_, err := js.Publish(subject, data, nats.MsgId(actionResponseID(response)))
+		return retryable(err)
+	})
+	if errors.Is(err, nats.ErrNoStreamResponse) {
+		return notification.NewError(notification.Internal, "no action stream is bound to "+subject+"; start an ActionRouter first")
+	}
+	if err != nil {
+		return notification.NewError(notification.Internal, "failed to publish action response: "+err.Error())
+	}
+
+	return nil
+}
+
+func actionResponseID(r *notification.ActionResponse) string {
+	return r.NotificationID + ":" + r.ActionID + ":" + r.UserID
+}
+
+// ActionRouterConfig configures an ActionRouter
+type ActionRouterConfig struct {
+	// Durable is the name of the JetStream consumer; instances sharing it split the work
+	Durable string
+	// AckWait is how long a handler may run before the response is redelivered
+	AckWait time.Duration
+	// MaxDeliver limits delivery attempts per response; zero means DefaultActionMaxDeliver
+	// and a negative value means unlimited
+	MaxDeliver int
+	// Redelivery spaces out deliveries of a response whose handler failed, by the number of
+	// deliveries so far. Only the backoff fields are used; a zero InitialBackoff means
+	// DefaultActionRedelivery.
+	Redelivery RetryPolicy
+	// DuplicateWindow overrides DefaultActionDuplicateWindow when the stream is created
+	DuplicateWindow time.Duration
+	// ErrorHandler is called for responses that cannot be decoded or handled; optional
+	ErrorHandler func(error)
+	// Security authenticates the connection and enables TLS; optional
+	Security *SecurityConfig
+}
+
+// ActionRouter consumes action responses from JetStream and routes them to the handler
+// registered for the notification's source
+type ActionRouter struct {
+	nc            *nats.Conn
+	js            nats.JetStreamContext
+	subjectPrefix string
+	config        ActionRouterConfig
+
+	mu       sync.RWMutex
+	handlers map[string]notification.ActionHandler
+	sub      *nats.Subscription
+}
+
+// NewActionRouter creates a router for the action responses published under subjectPrefix
+func NewActionRouter(natsURL, subjectPrefix string, config ActionRouterConfig) (*ActionRouter, error) {
+	if config.Durable == "" {
+		return nil, notification.NewError(notification.InvalidArguments, "durable consumer name cannot be empty")
+	}
+
+	nc, err := natsutil.ConnectSecure(natsURL, config.Security, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := natsutil.CreateJetStreamContext(nc)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	if config.DuplicateWindow <= 0 {
+		config.DuplicateWindow = DefaultActionDuplicateWindow
+	}
+	switch {
+	case config.MaxDeliver == 0:
+		config.MaxDeliver = DefaultActionMaxDeliver
+	case config.MaxDeliver < 0:
+		config.MaxDeliver = -1
+	}
+	if config.Redelivery.InitialBackoff <= 0 {
+		config.Redelivery = DefaultActionRedelivery()
+	}
+
+	return &ActionRouter{
+		nc:            nc,
+		js:            js,
+		subjectPrefix: subjectPrefix,
+		config:        config,
+		handlers:      make(map[string]notification.ActionHandler),
+	}, nil
+}
+
+// Handle registers the handler for responses to notifications published by source
+func (r *ActionRouter) Handle(source string, handler notification.ActionHandler) error {
+	if err := validation.ValidateSource(source); err != nil {
+		return err
+	}
+	if handler == nil {
+		return notification.NewError(notification.InvalidArguments, "action handler cannot be nil")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.handlers[source]; exists {
+		return notification.NewError(notification.AlreadyExists, "action handler already registered for source "+source)
+	}
+	r.handlers[source] = handler
+	return nil
+}
+
+// Start provisions the action stream if needed and begins consuming responses
+func (r *ActionRouter) Start() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.sub != nil {
+		return notification.NewError(notification.AlreadyExists, "action router already started")
+	}
+
+	subject := r.subjectPrefix + "." + natsutil.ActionsToken + ".>"
+	if err := natsutil.ValidateSubscribeSubject(subject); err != nil {
+		return err
+	}
+	stream := natsutil.ActionStreamName(r.subjectPrefix)
+	err := natsutil.EnsureStream(r.js, &nats.StreamConfig{
+		Name:       stream,
+		Subjects:   []string{subject},
+		Storage:    nats.FileStorage,
+		Duplicates: r.config.DuplicateWindow,
+	})
+	if err != nil {
+		return err
+	}
+
+	// The consumer is created here rather than by the subscription so that closing the
+	// router does not delete it and a restarted router resumes where it stopped
+	if _, err := r.js.ConsumerInfo(stream, r.config.Durable); errors.Is(err, nats.ErrConsumerNotFound) {
+		_, err = r.js.AddConsumer(stream, &nats.ConsumerConfig{
+			Durable:        r.config.Durable,
+			DeliverSubject: nats.NewInbox(),
+			DeliverGroup:   r.config.Durable,
+			DeliverPolicy:  nats.DeliverAllPolicy,
+			AckPolicy:      nats.AckExplicitPolicy,
+			AckWait:        r.config.AckWait,
+			MaxDeliver:     r.config.MaxDeliver,
+			FilterSubject:  subject,
+		})
+		if err != nil {
+			return notification.NewError(notification.Internal, "failed to create consumer "+r.config.Durable+": "+err.Error())
+		}
+	} else if err != nil {
+		return notification.NewError(notification.Internal, "failed to look up consumer "+r.config.Durable+": "+err.Error())
+	}
+
+	sub, err := r.js.QueueSubscribe(subject, r.config.Durable, r.handleMsg, nats.Bind(stream, r.config.Durable), nats.ManualAck())
+	if err != nil {
+		return notification.NewError(notification.Internal, "failed to subscribe to "+subject+": "+err.Error())
+	}
+
+	r.sub = sub
+	return nil
+}
+
+// handleMsg routes a single response and settles it: handled responses are acked
+// synchronously so the server confirms they will not be redelivered, failed ones are
+// nak'ed for a delayed redelivery and unroutable ones are terminated
+func (r *ActionRouter) handleMsg(msg *nats.Msg) {
+	response, err := utils.UnmarshalActionResponse(msg.Data)
+	if err == nil {
+		err = validation.ValidateActionResponse(response)
+	}
+	if err != nil {
+		r.handleError(err)
+		_ = msg.Term()
+		return
+	}
+
+	r.mu.RLock()
+	handler, ok := r.handlers[response.Source]
+	r.mu.RUnlock()
+	if !ok {
+		r.handleError(notification.NewError(notification.NotFound, "no action handler registered for source "+response.Source))
+		_ = msg.Term()
+		return
+	}
+
+	if err := handler(context.Background(), response); err != nil {
+		r.handleError(err)
+		_ = msg.NakWithDelay(r.redeliveryDelay(msg))
+		return
+	}
+
+	if err := msg.AckSync(); err != nil {
+		r.handleError(notification.NewError(notification.Internal, "failed to ack action response: "+err.Error()))
+	}
+}
+
+// redeliveryDelay backs off with every delivery of a response so a failing handler is not
+// retried in a tight loop
+func (r *ActionRouter) redeliveryDelay(msg *nats.Msg) time.Duration {
+	deliveries := 1
+	if meta, err := msg.Metadata(); err == nil {
+		deliveries = int(meta.NumDelivered)
+	}
+	return r.config.Redelivery.Backoff(deliveries)
+}
+
+func (r *ActionRouter) handleError(err error) {
+	if r.config.ErrorHandler != nil {
+		r.config.ErrorHandler(err)
+	}
+}
+
+// Close stops consuming, lets in-flight handlers finish and closes the NATS connection.
+// It returns once the connection is closed, or after the connection's drain timeout when
+// handlers are still running. The durable consumer is kept so a restarted router resumes
+// where it stopped.
+func (r *ActionRouter) Close() error {
+	r.mu.Lock()
+	r.sub = nil
+	nc := r.nc
+	r.mu.Unlock()
+
+	// Handlers take r.mu to look up routes, so the drain must not run under it
+	if nc == nil || nc.IsClosed() {
+		return nil
+	}
+	if err := nc.Drain(); err != nil {
+		nc.Close()
+		return notification.NewError(notification.Internal, "failed to drain action router: "+err.Error())
+	}
+	if !waitClosed(nc, nc.Opts.DrainTimeout+time.Second) {
+		nc.Close()
+		return notification.NewError(notification.Internal, "failed to drain action router: handlers still running after "+nc.Opts.DrainTimeout.String())
+	}
+	return nil
+}
+
+// waitClosed polls until nc is closed, reporting false when timeout passes first
+func waitClosed(nc *nats.Conn, timeout time.Duration) bool {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+	for !nc.IsClosed() {
+		select {
+		case <-deadline.C:
+			return false
+		case <-ticker.C:
+		}
+	}
+	return true
+}
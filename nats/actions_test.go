@@ -0,0 +1,173 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/MyWeHub/notification-sdk/internal/natsutil"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionRouter(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	prefix := "test-actions"
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatalf("Failed to create JetStream context: %v", err)
+	}
+	_ = js.DeleteStream(natsutil.ActionStreamName(prefix))
+	defer js.DeleteStream(natsutil.ActionStreamName(prefix))
+
+	router, err := NewActionRouter(nats.DefaultURL, prefix, ActionRouterConfig{Durable: "test-router", AckWait: time.Second})
+	if err != nil {
+		t.Fatalf("Failed to create action router: %v", err)
+	}
+	defer router.Close()
+
+	var mu sync.Mutex
+	var handled []*notification.ActionResponse
+	failures := 1
+	var failedAt, retriedAt time.Time
+	err = router.Handle("order-service", func(_ context.Context, r *notification.ActionResponse) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.ActionID == "reject" && failures > 0 {
+			failures--
+			failedAt = time.Now()
+			return errors.New("temporary failure")
+		}
+		if r.ActionID == "reject" {
+			retriedAt = time.Now()
+		}
+		handled = append(handled, r)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Error(t, router.Handle("order-service", func(context.Context, *notification.ActionResponse) error { return nil }))
+
+	if err := router.Start(); err != nil {
+		t.Fatalf("Failed to start action router: %v", err)
+	}
+	info, err := js.ConsumerInfo(natsutil.ActionStreamName(prefix), "test-router")
+	if err != nil {
+		t.Fatalf("Failed to look up consumer: %v", err)
+	}
+	assert.Equal(t, DefaultActionMaxDeliver, info.Config.MaxDeliver)
+
+	publisher, err := NewPublisher(nats.DefaultURL, prefix)
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	approve := &notification.ActionResponse{NotificationID: "n-1", ActionID: "approve", UserID: "user-1", Source: "order-service"}
+	for i := 0; i < 3; i++ {
+		// Retried publishes of the same response are deduplicated by the stream
+		assert.NoError(t, publisher.PublishActionResponse(approve))
+	}
+	assert.NoError(t, publisher.PublishActionResponse(&notification.ActionResponse{
+		NotificationID: "n-2", ActionID: "reject", UserID: "user-1", Source: "order-service",
+	}))
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(handled) == 2
+	}, 5*time.Second, 20*time.Millisecond)
+
+	time.Sleep(200 * time.Millisecond)
+	mu.Lock()
+	assert.Len(t, handled, 2)
+	assert.Equal(t, "approve", handled[0].ActionID)
+	assert.False(t, handled[0].Timestamp.IsZero())
+	assert.Equal(t, "reject", handled[1].ActionID)
+	// The failed response is redelivered after a backoff, not at once
+	assert.GreaterOrEqual(t, retriedAt.Sub(failedAt), 900*time.Millisecond)
+	mu.Unlock()
+
+	err = publisher.PublishActionResponse(&notification.ActionResponse{NotificationID: "n-1", ActionID: "approve", Source: "order-service"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "userID cannot be empty")
+}
+
+func TestPublishActionResponseWithoutStream(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	publisher, err := NewPublisher(nats.DefaultURL, "test-actions-unbound")
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	err = publisher.PublishActionResponse(&notification.ActionResponse{
+		NotificationID: "n-1", ActionID: "approve", UserID: "user-1", Source: "order-service",
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no action stream")
+}
+
+func TestActionRouterCloseWaitsForHandlers(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	prefix := "test-actions-close"
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatalf("Failed to create JetStream context: %v", err)
+	}
+	_ = js.DeleteStream(natsutil.ActionStreamName(prefix))
+	defer js.DeleteStream(natsutil.ActionStreamName(prefix))
+
+	router, err := NewActionRouter(nats.DefaultURL, prefix, ActionRouterConfig{Durable: "test-router-close"})
+	if err != nil {
+		t.Fatalf("Failed to create action router: %v", err)
+	}
+
+	started := make(chan struct{})
+	var finished atomic.Bool
+	assert.NoError(t, router.Handle("order-service", func(context.Context, *notification.ActionResponse) error {
+		close(started)
+		time.Sleep(300 * time.Millisecond)
+		finished.Store(true)
+		return nil
+	}))
+	if err := router.Start(); err != nil {
+		t.Fatalf("Failed to start action router: %v", err)
+	}
+
+	publisher, err := NewPublisher(nats.DefaultURL, prefix)
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+	assert.NoError(t, publisher.PublishActionResponse(&notification.ActionResponse{
+		NotificationID: "n-1", ActionID: "approve", UserID: "user-1", Source: "order-service",
+	}))
+
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for handler")
+	}
+	assert.NoError(t, router.Close())
+	assert.True(t, finished.Load())
+	assert.True(t, router.nc.IsClosed())
+}
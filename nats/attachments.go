@@ -0,0 +1,181 @@
+package nats
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/MyWeHub/notification-sdk/internal/validation"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+)
+
+// AttachmentStore stores notification attachments in JetStream Object Store buckets
+type AttachmentStore struct {
+	js nats.JetStreamContext
+
+	mu      sync.Mutex
+	buckets map[string]nats.ObjectStore
+}
+
+// NewAttachmentStore creates an attachment store on top of a JetStream context
+func NewAttachmentStore(js nats.JetStreamContext) *AttachmentStore {
+	return &AttachmentStore{
+		js:      js,
+		buckets: make(map[string]nats.ObjectStore),
+	}
+}
+
+// Attachments returns the attachment store backed by the publisher's connection
+func (p *Publisher) Attachments() *AttachmentStore {
+	p.attachmentsOnce.Do(func() {
+		p.attachments = NewAttachmentStore(p.js)
+	})
+	return p.attachments
+}
+
+// UploadAttachment uploads a blob to bucket, creating the bucket if needed, and returns
+// the reference to set on Notification.Attachments
+func (p *Publisher) UploadAttachment(bucket, name, contentType string, r io.Reader) (*notification.Attachment, error) {
+	if err := p.begin(); err != nil {
+		return nil, err
+	}
+	defer p.end()
+	return p.Attachments().Upload(bucket, name, contentType, r)
+}
+
+// Upload stores a blob in bucket, creating the bucket if needed. Blobs larger than the
+// validation limit are rejected and removed.
+func (s *AttachmentStore) Upload(bucket, name, contentType string, r io.Reader) (*notification.Attachment, error) {
+	if name == "" {
+		return nil, notification.NewError(notification.InvalidArguments, "attachment name cannot be empty")
+	}
+
+	store, err := s.bucket(bucket, true)
+	if err != nil {
+		return nil, err
+	}
+
+	key := uuid.New().String()
+	limited := &limitedReader{r: r, remaining: validation.MaxAttachmentSize}
+	info, err := store.Put(&nats.ObjectMeta{
+		Name:        key,
+		Description: name,
+		Headers:     nats.Header{"Content-Type": []string{contentType}},
+	}, limited)
+	if err != nil {
+		_ = store.Delete(key)
+		if errors.Is(err, errAttachmentTooLarge) {
+			return nil, notification.NewError(notification.InvalidArguments, "attachment "+name+" cannot exceed "+strconv.Itoa(validation.MaxAttachmentSize)+" bytes")
+		}
+		return nil, notification.NewError(notification.Internal, "failed to upload attachment "+name+": "+err.Error())
+	}
+
+	attachment := &notification.Attachment{
+		Name:        name,
+		Size:        int64(info.Size),
+		ContentType: contentType,
+		Digest:      info.Digest,
+		Bucket:      bucket,
+		Key:         key,
+	}
+	if err := validation.ValidateAttachment(*attachment); err != nil {
+		_ = store.Delete(key)
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// Open returns a reader over the contents of an attachment. The digest is verified as
+// the reader reaches the end of the blob.
+func (s *AttachmentStore) Open(attachment notification.Attachment) (io.ReadCloser, error) {
+	if err := validation.ValidateAttachment(attachment); err != nil {
+		return nil, err
+	}
+
+	store, err := s.bucket(attachment.Bucket, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := store.Get(attachment.Key)
+	if errors.Is(err, nats.ErrObjectNotFound) {
+		return nil, notification.NewError(notification.NotFound, "attachment "+attachment.Name+" not found")
+	}
+	if err != nil {
+		return nil, notification.NewError(notification.Internal, "failed to fetch attachment "+attachment.Name+": "+err.Error())
+	}
+
+	info, err := result.Info()
+	if err == nil && attachment.Digest != "" && info.Digest != attachment.Digest {
+		result.Close()
+		return nil, notification.NewError(notification.InvalidArguments, "attachment "+attachment.Name+" digest does not match the stored object")
+	}
+
+	return result, nil
+}
+
+// Delete removes an attachment from its bucket
+func (s *AttachmentStore) Delete(attachment notification.Attachment) error {
+	store, err := s.bucket(attachment.Bucket, false)
+	if err != nil {
+		return err
+	}
+
+	if err := store.Delete(attachment.Key); err != nil && !errors.Is(err, nats.ErrObjectNotFound) {
+		return notification.NewError(notification.Internal, "failed to delete attachment "+attachment.Name+": "+err.Error())
+	}
+	return nil
+}
+
+// bucket returns the object store for a bucket, optionally creating it
+func (s *AttachmentStore) bucket(name string, create bool) (nats.ObjectStore, error) {
+	js, err := requireJetStream(s.js)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, notification.NewError(notification.InvalidArguments, "attachment bucket cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if store, ok := s.buckets[name]; ok {
+		return store, nil
+	}
+
+	store, err := js.ObjectStore(name)
+	if errors.Is(err, nats.ErrStreamNotFound) {
+		if !create {
+			return nil, notification.NewError(notification.NotFound, "attachment bucket "+name+" not found")
+		}
+		store, err = js.CreateObjectStore(&nats.ObjectStoreConfig{Bucket: name})
+	}
+	if err != nil {
+		return nil, notification.NewError(notification.Internal, "failed to open attachment bucket "+name+": "+err.Error())
+	}
+
+	s.buckets[name] = store
+	return store, nil
+}
+
+var errAttachmentTooLarge = errors.New("attachment too large")
+
+// limitedReader fails once more than remaining bytes are read, aborting the upload
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, errAttachmentTooLarge
+	}
+	return n, err
+}
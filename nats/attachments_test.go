@@ -0,0 +1,114 @@
+package nats
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/MyWeHub/notification-sdk/internal/validation"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachments(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatalf("Failed to create JetStream context: %v", err)
+	}
+	_ = js.DeleteObjectStore("test-attachments")
+	defer js.DeleteObjectStore("test-attachments")
+
+	publisher, err := NewPublisher(nats.DefaultURL, "test-notifications")
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	content := "id,amount\n1,10.00\n2,12.50\n"
+	attachment, err := publisher.UploadAttachment("test-attachments", "export.csv", "text/csv", strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Failed to upload attachment: %v", err)
+	}
+	assert.Equal(t, "export.csv", attachment.Name)
+	assert.Equal(t, int64(len(content)), attachment.Size)
+	assert.Equal(t, "text/csv", attachment.ContentType)
+	assert.Equal(t, "test-attachments", attachment.Bucket)
+	assert.NotEmpty(t, attachment.Key)
+	assert.True(t, strings.HasPrefix(attachment.Digest, "SHA-256="))
+
+	err = publisher.PublishCustomNotification("test-client", &notification.Notification{
+		ClientID:    "test-client",
+		Title:       "Export ready",
+		Message:     "Your export is attached",
+		Source:      "export-service",
+		Attachments: []notification.Attachment{*attachment},
+	})
+	assert.NoError(t, err)
+
+	consumer := NewAttachmentStore(js)
+	reader, err := consumer.Open(*attachment)
+	if err != nil {
+		t.Fatalf("Failed to open attachment: %v", err)
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	assert.NoError(t, err)
+	assert.Equal(t, content, string(data))
+
+	tampered := *attachment
+	tampered.Digest = "SHA-256=invalid"
+	_, err = consumer.Open(tampered)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "digest does not match")
+
+	assert.NoError(t, consumer.Delete(*attachment))
+	_, err = consumer.Open(*attachment)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+
+	_, err = consumer.Open(notification.Attachment{Name: "a", Bucket: "missing-bucket", Key: "k"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "bucket missing-bucket not found")
+}
+
+func TestUploadAttachmentTooLarge(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatalf("Failed to create JetStream context: %v", err)
+	}
+	defer js.DeleteObjectStore("test-attachments-large")
+
+	publisher, err := NewPublisher(nats.DefaultURL, "test-notifications")
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	blob := bytes.NewReader(make([]byte, validation.MaxAttachmentSize+1))
+	_, err = publisher.UploadAttachment("test-attachments-large", "huge.pdf", "application/pdf", blob)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot exceed")
+}
+
+func TestAttachmentsWithoutJetStream(t *testing.T) {
+	_, err := NewAttachmentStore(nil).Upload("bucket", "report.pdf", "application/pdf", strings.NewReader("data"))
+	if assert.Error(t, err) {
+		assert.EqualValues(t, notification.InvalidArguments, err.(*notification.Error).Code)
+		assert.Contains(t, err.Error(), "JetStream is disabled")
+	}
+}
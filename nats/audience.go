@@ -0,0 +1,120 @@
+package nats
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/MyWeHub/notification-sdk/internal/natsutil"
+	"github.com/MyWeHub/notification-sdk/internal/utils"
+	"github.com/MyWeHub/notification-sdk/internal/validation"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+// OrgBroadcastSubject returns the subject organization-wide notifications are published
+// on, for gateways that fan them out to connected users
+func OrgBroadcastSubject(subjectPrefix, orgID string) string {
+	return natsutil.BuildOrgBroadcastSubject(subjectPrefix, orgID)
+}
+
+// WithAudienceResolver sets the resolver PublishToAudience expands role, user list and
+// segment audiences with
+func WithAudienceResolver(resolver notification.AudienceResolverPort) Option {
+	return func(o *publisherOptions) {
+		o.audiences = resolver
+	}
+}
+
+// PublishToAudience publishes a notification to every recipient of an audience.
+// Organization audiences are published once on the organization's broadcast subject;
+// other audiences are expanded by the audience resolver and published per recipient,
+// each copy with its own ID.
+func (p *Publisher) PublishToAudience(ctx context.Context, audience notification.Audience, notif *notification.Notification) error {
+	if err := validation.ValidateAudience(&audience); err != nil {
+		return err
+	}
+	if notif == nil {
+		return notification.NewError(notification.InvalidArguments, "notification cannot be nil")
+	}
+
+	if audience.Kind == notification.AudienceOrganization {
+		broadcast := p.audienceCopy(notif, audience, audience.OrgID, "")
+		if err := validation.ValidateNotification(broadcast); err != nil {
+			return err
+		}
+		if err := p.authorize(notification.ContextWithOrgBroadcast(ctx, audience.OrgID), broadcast); err != nil {
+			return err
+		}
+		return p.publishToSubject(natsutil.BuildOrgBroadcastSubject(p.subjectPrefix, audience.OrgID), broadcast)
+	}
+
+	if p.audiences == nil {
+		return notification.NewError(notification.InvalidArguments, "publisher has no audience resolver configured")
+	}
+
+	recipients, err := p.audiences.Resolve(ctx, audience)
+	if err != nil {
+		return err
+	}
+
+	copies := make([]*notification.Notification, 0, len(recipients))
+	for _, recipient := range recipients {
+		copies = append(copies, p.audienceCopy(notif, audience, recipient.ClientID, recipient.UserID))
+	}
+	return p.fanOut(ctx, copies)
+}
+
+// fanOut publishes per-recipient copies of a notification. Every copy is validated and
+// authorized up front so an invalid notification is not half delivered; publish failures
+// do not stop the remaining recipients.
+func (p *Publisher) fanOut(ctx context.Context, copies []*notification.Notification) error {
+	for _, c := range copies {
+		if err := validation.ValidateNotification(c); err != nil {
+			return err
+		}
+		if err := p.authorize(ctx, c); err != nil {
+			return err
+		}
+	}
+
+	failed := 0
+	var lastErr error
+	for _, c := range copies {
+		if err := ctx.Err(); err != nil {
+			return notification.NewError(notification.Internal, "fan-out interrupted: "+err.Error())
+		}
+		if err := p.publishAuthorized(c); err != nil {
+			failed++
+			lastErr = err
+		}
+	}
+	if failed > 0 {
+		return notification.NewError(notification.Internal, "failed to publish to "+strconv.Itoa(failed)+" of "+
+			strconv.Itoa(len(copies))+" recipients: "+lastErr.Error())
+	}
+
+	return nil
+}
+
+// audienceCopy clones a notification for a single target of an audience
+func (p *Publisher) audienceCopy(notif *notification.Notification, audience notification.Audience, clientID, userID string) *notification.Notification {
+	c := p.recipientCopy(notif, clientID, userID)
+	c.Audience = &audience
+	return c
+}
+
+// recipientCopy clones a notification for a single recipient, giving it its own ID. The
+// caller's Audience is dropped; only audienceCopy sets one.
+func (p *Publisher) recipientCopy(notif *notification.Notification, clientID, userID string) *notification.Notification {
+	c := *notif
+	c.ID = p.newID()
+	c.ClientID = clientID
+	c.Audience = nil
+	if userID != "" {
+		c.UserID = userID
+	}
+	if utils.IsZeroTime(c.CreatedAt) {
+		c.CreatedAt = p.now()
+	}
+	return &c
+}
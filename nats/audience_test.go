@@ -0,0 +1,96 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/MyWeHub/notification-sdk/memory"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishToAudience(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	publisher, err := NewPublisher(nats.DefaultURL, "test-audience")
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	var mu sync.Mutex
+	received := make(map[string][]*notification.Notification)
+	subscription, err := nc.Subscribe("test-audience.>", func(msg *nats.Msg) {
+		var n notification.Notification
+		if err := json.Unmarshal(msg.Data, &n); err != nil {
+			t.Errorf("Failed to unmarshal notification: %v", err)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		received[msg.Subject] = append(received[msg.Subject], &n)
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe to NATS: %v", err)
+	}
+	defer subscription.Unsubscribe()
+	if err := nc.Flush(); err != nil {
+		t.Fatalf("Failed to flush connection: %v", err)
+	}
+
+	template := &notification.Notification{Title: "Maintenance", Message: "Tonight at 10pm", Source: "ops", Type: notification.TypeSystem}
+	ctx := context.Background()
+
+	// Organization audiences are a single broadcast publish
+	err = publisher.PublishToAudience(ctx, notification.Audience{Kind: notification.AudienceOrganization, OrgID: "org-a"}, template)
+	assert.NoError(t, err)
+
+	// Other audiences need a resolver
+	err = publisher.PublishToAudience(ctx, notification.Audience{Kind: notification.AudienceRole, OrgID: "org-a", Role: "admin"}, template)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no audience resolver configured")
+
+	directory := memory.NewAudienceDirectory()
+	directory.AddUser("u-1", "client-1", "org-a", "admin")
+	directory.AddUser("u-2", "client-2", "org-a", "admin")
+	directory.AddUser("u-3", "client-3", "org-a")
+	publisher, err = New(WithURL(nats.DefaultURL), WithSubjectPrefix("test-audience"), WithAudienceResolver(directory))
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	err = publisher.PublishToAudience(ctx, notification.Audience{Kind: notification.AudienceRole, OrgID: "org-a", Role: "admin"}, template)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 3
+	}, 3*time.Second, 20*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	broadcast := received[OrgBroadcastSubject("test-audience", "org-a")]
+	assert.Len(t, broadcast, 1)
+	assert.Equal(t, "org-a", broadcast[0].ClientID)
+	assert.Equal(t, notification.AudienceOrganization, broadcast[0].Audience.Kind)
+
+	first := received["test-audience.client-1"]
+	second := received["test-audience.client-2"]
+	assert.Len(t, first, 1)
+	assert.Len(t, second, 1)
+	assert.Equal(t, "u-1", first[0].UserID)
+	assert.Equal(t, "admin", first[0].Audience.Role)
+	assert.NotEqual(t, first[0].ID, second[0].ID)
+	assert.Empty(t, received["test-audience.client-3"])
+	assert.Empty(t, template.ID, "the caller's notification must not be modified")
+}
@@ -0,0 +1,291 @@
+package nats
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/MyWeHub/notification-sdk/internal/natsutil"
+	"github.com/MyWeHub/notification-sdk/internal/utils"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultChannelTimeout bounds a single channel delivery when no timeout is configured
+const DefaultChannelTimeout = 10 * time.Second
+
+// DispatcherConfig configures a Dispatcher
+type DispatcherConfig struct {
+	// Adapters are the channel adapters notifications can be routed to
+	Adapters []notification.ChannelAdapter
+	// Preferences resolves organization preferences; optional
+	Preferences notification.PreferencesPort
+	// Recorder receives one outcome per channel delivery attempt; optional
+	Recorder notification.DeliveryRecorder
+	// Timeout applies to every channel without an entry in ChannelTimeouts
+	Timeout time.Duration
+	// ChannelTimeouts overrides Timeout for individual channels
+	ChannelTimeouts map[notification.Channel]time.Duration
+	// SubjectTemplate must match the template of the publishers; defaults to DefaultSubjectTemplate
+	SubjectTemplate string
+	// Codec decodes received notifications and must match the publishers'; defaults to JSONCodec
+	Codec Codec
+	// QueueGroup load-balances notifications across dispatcher instances when set
+	QueueGroup string
+	// ErrorHandler is called for notifications that cannot be decoded or routed; optional
+	ErrorHandler func(error)
+	// Security authenticates the connection and enables TLS; optional
+	Security *SecurityConfig
+}
+
+// Dispatcher consumes notifications from NATS and fans them out to channel adapters
+type Dispatcher struct {
+	nc            *nats.Conn
+	subjectPrefix string
+	config        DispatcherConfig
+	adapters      map[notification.Channel]notification.ChannelAdapter
+
+	mu  sync.Mutex
+	sub *nats.Subscription
+}
+
+// NewDispatcher creates a new dispatcher consuming notifications published under subjectPrefix
+func NewDispatcher(natsURL, subjectPrefix string, config DispatcherConfig) (*Dispatcher, error) {
+	d := newDispatcher(nil, subjectPrefix, config)
+	if _, err := d.subjectScheme(); err != nil {
+		return nil, err
+	}
+
+	nc, err := natsutil.ConnectSecure(natsURL, config.Security, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	d.nc = nc
+	return d, nil
+}
+
+func newDispatcher(nc *nats.Conn, subjectPrefix string, config DispatcherConfig) *Dispatcher {
+	adapters := make(map[notification.Channel]notification.ChannelAdapter, len(config.Adapters))
+	for _, adapter := range config.Adapters {
+		adapters[adapter.Channel()] = adapter
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultChannelTimeout
+	}
+	if config.Codec == nil {
+		config.Codec = JSONCodec{}
+	}
+	if config.SubjectTemplate == "" {
+		config.SubjectTemplate = natsutil.DefaultSubjectTemplate
+	}
+
+	return &Dispatcher{
+		nc:            nc,
+		subjectPrefix: subjectPrefix,
+		config:        config,
+		adapters:      adapters,
+	}
+}
+
+// Start subscribes to the notification subject and begins dispatching
+func (d *Dispatcher) Start() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.sub != nil {
+		return notification.NewError(notification.AlreadyExists, "dispatcher already started")
+	}
+
+	scheme, err := d.subjectScheme()
+	if err != nil {
+		return err
+	}
+
+	subject := scheme.Wildcard(natsutil.SubjectParts{})
+	if err := natsutil.ValidateSubscribeSubject(subject); err != nil {
+		return err
+	}
+	handler := func(msg *nats.Msg) {
+		// Copies published for subscribers still on the legacy encoding would be delivered twice
+		if msg.Header.Get(LegacySubjectHeader) != "" {
+			return
+		}
+		// Status, action and broadcast subjects match the wildcard of some templates
+		if natsutil.IsReservedSubject(d.subjectPrefix, msg.Subject) {
+			return
+		}
+
+		notif, err := d.config.Codec.Decode(msg.Data)
+		if err != nil {
+			d.handleError(err)
+			return
+		}
+		d.Dispatch(context.Background(), notif)
+	}
+
+	var sub *nats.Subscription
+	if d.config.QueueGroup != "" {
+		sub, err = d.nc.QueueSubscribe(subject, d.config.QueueGroup, handler)
+	} else {
+		sub, err = d.nc.Subscribe(subject, handler)
+	}
+	if err != nil {
+		return notification.NewError(notification.Internal, "failed to subscribe to "+subject+": "+err.Error())
+	}
+
+	d.sub = sub
+	return nil
+}
+
+func (d *Dispatcher) subjectScheme() (*natsutil.SubjectScheme, error) {
+	return natsutil.NewSubjectScheme(d.config.SubjectTemplate, d.subjectPrefix)
+}
+
+// Dispatch delivers a notification to every resolved channel concurrently and returns
+// the outcome of each delivery attempt
+func (d *Dispatcher) Dispatch(ctx context.Context, notif *notification.Notification) []notification.DeliveryOutcome {
+	channels := resolveChannels(notif, d.loadPreferences(ctx, notif))
+
+	outcomes := make([]notification.DeliveryOutcome, len(channels))
+	var wg sync.WaitGroup
+	for i, channel := range channels {
+		wg.Add(1)
+		go func(i int, channel notification.Channel) {
+			defer wg.Done()
+			outcomes[i] = d.deliver(ctx, channel, notif)
+		}(i, channel)
+	}
+	wg.Wait()
+
+	if d.config.Recorder != nil {
+		for _, outcome := range outcomes {
+			if err := d.config.Recorder.RecordDelivery(ctx, outcome); err != nil {
+				d.handleError(err)
+			}
+		}
+	}
+
+	return outcomes
+}
+
+// deliver invokes the adapter for a single channel under the channel's timeout
+func (d *Dispatcher) deliver(ctx context.Context, channel notification.Channel, notif *notification.Notification) notification.DeliveryOutcome {
+	outcome := notification.DeliveryOutcome{
+		NotificationID: notif.ID,
+		Channel:        channel,
+		AttemptedAt:    utils.UTCNow(),
+	}
+
+	adapter, ok := d.adapters[channel]
+	if !ok {
+		outcome.Error = "no adapter registered for channel " + string(channel)
+		return outcome
+	}
+
+	timeout := d.config.Timeout
+	if t, ok := d.config.ChannelTimeouts[channel]; ok && t > 0 {
+		timeout = t
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Run the adapter in its own goroutine so a misbehaving adapter that ignores
+	// ctx cannot hold up the other channels past the timeout
+	done := make(chan error, 1)
+	go func() {
+		done <- adapter.Deliver(ctx, notif)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	outcome.Duration = time.Since(outcome.AttemptedAt)
+	if err != nil {
+		outcome.Error = err.Error()
+		return outcome
+	}
+	outcome.Delivered = true
+	return outcome
+}
+
+// loadPreferences fetches the preferences of the notification's organization, if configured
+func (d *Dispatcher) loadPreferences(ctx context.Context, notif *notification.Notification) *notification.OrganizationNotificationPreferences {
+	if d.config.Preferences == nil {
+		return nil
+	}
+
+	orgID := notif.Metadata[notification.MetadataOrgID]
+	if orgID == "" {
+		orgID = notif.ClientID
+	}
+
+	prefs, err := d.config.Preferences.GetPreferences(ctx, orgID)
+	if err != nil {
+		d.handleError(err)
+		return nil
+	}
+	return prefs
+}
+
+func (d *Dispatcher) handleError(err error) {
+	if d.config.ErrorHandler != nil {
+		d.config.ErrorHandler(err)
+	}
+}
+
+// Close stops consuming and closes the NATS connection
+func (d *Dispatcher) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.sub != nil {
+		if err := d.sub.Unsubscribe(); err != nil && err != nats.ErrConnectionClosed {
+			return notification.NewError(notification.Internal, "failed to unsubscribe dispatcher: "+err.Error())
+		}
+		d.sub = nil
+	}
+	if d.nc != nil {
+		d.nc.Close()
+	}
+	return nil
+}
+
+// resolveChannels determines the target channels of a notification. Channels listed on the
+// notification take precedence, otherwise it is delivered in-app. The email channel is added
+// when the notification's workflow has email enabled and removed when it is disabled.
+func resolveChannels(notif *notification.Notification, prefs *notification.OrganizationNotificationPreferences) []notification.Channel {
+	channels := notif.Channels
+	if len(channels) == 0 {
+		channels = []notification.Channel{notification.ChannelInApp}
+	}
+
+	emailEnabled, hasWorkflowPref := false, false
+	if prefs != nil {
+		if workflowID := notif.Metadata[notification.MetadataWorkflowID]; workflowID != "" {
+			var pref notification.WorkflowEmailPreference
+			pref, hasWorkflowPref = prefs.Workflows[workflowID]
+			emailEnabled = pref.Enabled && len(prefs.InternalEmails)+len(prefs.ExternalEmails) > 0
+		}
+	}
+
+	seen := make(map[notification.Channel]bool, len(channels)+1)
+	resolved := make([]notification.Channel, 0, len(channels)+1)
+	for _, channel := range channels {
+		if seen[channel] || (channel == notification.ChannelEmail && hasWorkflowPref && !emailEnabled) {
+			continue
+		}
+		seen[channel] = true
+		resolved = append(resolved, channel)
+	}
+	if emailEnabled && !seen[notification.ChannelEmail] {
+		resolved = append(resolved, notification.ChannelEmail)
+	}
+
+	return resolved
+}
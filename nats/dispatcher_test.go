@@ -0,0 +1,246 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeAdapter struct {
+	channel notification.Channel
+	delay   time.Duration
+	err     error
+
+	mu        sync.Mutex
+	delivered []*notification.Notification
+}
+
+func (a *fakeAdapter) Channel() notification.Channel {
+	return a.channel
+}
+
+func (a *fakeAdapter) Deliver(ctx context.Context, n *notification.Notification) error {
+	select {
+	case <-time.After(a.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.delivered = append(a.delivered, n)
+	return a.err
+}
+
+type fakePreferences map[string]*notification.OrganizationNotificationPreferences
+
+func (f fakePreferences) GetPreferences(_ context.Context, orgID string) (*notification.OrganizationNotificationPreferences, error) {
+	return f[orgID], nil
+}
+
+type fakeRecorder struct {
+	mu       sync.Mutex
+	outcomes []notification.DeliveryOutcome
+}
+
+func (r *fakeRecorder) RecordDelivery(_ context.Context, outcome notification.DeliveryOutcome) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.outcomes = append(r.outcomes, outcome)
+	return nil
+}
+
+func TestResolveChannels(t *testing.T) {
+	prefs := &notification.OrganizationNotificationPreferences{
+		InternalEmails: []string{"ops@example.com"},
+		Workflows: map[string]notification.WorkflowEmailPreference{
+			"invoices": {Enabled: true},
+			"reports":  {Enabled: false},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		notif    *notification.Notification
+		prefs    *notification.OrganizationNotificationPreferences
+		expected []notification.Channel
+	}{
+		{"defaults to in-app", &notification.Notification{}, nil, []notification.Channel{notification.ChannelInApp}},
+		{"explicit channels", &notification.Notification{Channels: []notification.Channel{notification.ChannelSMS, notification.ChannelSMS}}, nil, []notification.Channel{notification.ChannelSMS}},
+		{"workflow enables email", &notification.Notification{Metadata: map[string]string{notification.MetadataWorkflowID: "invoices"}}, prefs, []notification.Channel{notification.ChannelInApp, notification.ChannelEmail}},
+		{"workflow disables email", &notification.Notification{Channels: []notification.Channel{notification.ChannelEmail, notification.ChannelPush}, Metadata: map[string]string{notification.MetadataWorkflowID: "reports"}}, prefs, []notification.Channel{notification.ChannelPush}},
+		{"unknown workflow keeps explicit email", &notification.Notification{Channels: []notification.Channel{notification.ChannelEmail}, Metadata: map[string]string{notification.MetadataWorkflowID: "other"}}, prefs, []notification.Channel{notification.ChannelEmail}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, resolveChannels(tt.notif, tt.prefs))
+		})
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	inApp := &fakeAdapter{channel: notification.ChannelInApp}
+	email := &fakeAdapter{channel: notification.ChannelEmail, err: errors.New("smtp unavailable")}
+	push := &fakeAdapter{channel: notification.ChannelPush, delay: time.Second}
+	recorder := &fakeRecorder{}
+
+	dispatcher := newDispatcher(nil, "test-notifications", DispatcherConfig{
+		Adapters:        []notification.ChannelAdapter{inApp, email, push},
+		Recorder:        recorder,
+		ChannelTimeouts: map[notification.Channel]time.Duration{notification.ChannelPush: 50 * time.Millisecond},
+	})
+
+	notif := &notification.Notification{
+		ID:       "notif-1",
+		Channels: []notification.Channel{notification.ChannelInApp, notification.ChannelEmail, notification.ChannelPush, notification.ChannelSMS},
+	}
+
+	start := time.Now()
+	outcomes := dispatcher.Dispatch(context.Background(), notif)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	assert.Len(t, outcomes, 4)
+	assert.True(t, outcomes[0].Delivered)
+	assert.False(t, outcomes[1].Delivered)
+	assert.Equal(t, "smtp unavailable", outcomes[1].Error)
+	assert.False(t, outcomes[2].Delivered)
+	assert.Equal(t, context.DeadlineExceeded.Error(), outcomes[2].Error)
+	assert.False(t, outcomes[3].Delivered)
+	assert.Contains(t, outcomes[3].Error, "no adapter registered")
+	for _, outcome := range outcomes {
+		assert.Equal(t, "notif-1", outcome.NotificationID)
+	}
+	assert.Len(t, recorder.outcomes, 4)
+}
+
+func TestDispatchUsesPreferences(t *testing.T) {
+	inApp := &fakeAdapter{channel: notification.ChannelInApp}
+	email := &fakeAdapter{channel: notification.ChannelEmail}
+
+	dispatcher := newDispatcher(nil, "test-notifications", DispatcherConfig{
+		Adapters: []notification.ChannelAdapter{inApp, email},
+		Preferences: fakePreferences{
+			"org-1": {
+				ExternalEmails: []string{"customer@example.com"},
+				Workflows:      map[string]notification.WorkflowEmailPreference{"billing": {Enabled: true}},
+			},
+		},
+	})
+
+	outcomes := dispatcher.Dispatch(context.Background(), &notification.Notification{
+		ID:       "notif-2",
+		ClientID: "client-1",
+		Metadata: map[string]string{
+			notification.MetadataOrgID:      "org-1",
+			notification.MetadataWorkflowID: "billing",
+		},
+	})
+
+	assert.Len(t, outcomes, 2)
+	assert.Len(t, inApp.delivered, 1)
+	assert.Len(t, email.delivered, 1)
+}
+
+func TestDispatcherConsumesNotifications(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	recorder := &fakeRecorder{}
+	dispatcher, err := NewDispatcher(nats.DefaultURL, "test-dispatch", DispatcherConfig{
+		Adapters: []notification.ChannelAdapter{&fakeAdapter{channel: notification.ChannelInApp}},
+		Recorder: recorder,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create dispatcher: %v", err)
+	}
+	defer dispatcher.Close()
+
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Failed to start dispatcher: %v", err)
+	}
+	if err := dispatcher.nc.Flush(); err != nil {
+		t.Fatalf("Failed to flush connection: %v", err)
+	}
+
+	publisher, err := NewPublisher(nats.DefaultURL, "test-dispatch")
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	err = publisher.PublishNotification("test-client", "Test Title", "Test message", notification.TypeInfo, "system")
+	if err != nil {
+		t.Fatalf("Failed to publish notification: %v", err)
+	}
+
+	assert.Eventually(t, func() bool {
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		return len(recorder.outcomes) == 1 && recorder.outcomes[0].Delivered
+	}, 3*time.Second, 20*time.Millisecond)
+}
+
+func TestDispatcherSkipsReservedSubjects(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	errs := make(chan error, 4)
+	recorder := &fakeRecorder{}
+	dispatcher, err := NewDispatcher(nats.DefaultURL, "test-dispatch-reserved", DispatcherConfig{
+		Adapters:        []notification.ChannelAdapter{&fakeAdapter{channel: notification.ChannelInApp}},
+		Recorder:        recorder,
+		SubjectTemplate: "{prefix}.{client}.{source}",
+		ErrorHandler:    func(err error) { errs <- err },
+	})
+	if err != nil {
+		t.Fatalf("Failed to create dispatcher: %v", err)
+	}
+	defer dispatcher.Close()
+
+	if err := dispatcher.Start(); err != nil {
+		t.Fatalf("Failed to start dispatcher: %v", err)
+	}
+	if err := dispatcher.nc.Flush(); err != nil {
+		t.Fatalf("Failed to flush connection: %v", err)
+	}
+
+	publisher, err := New(
+		WithURL(nats.DefaultURL),
+		WithSubjectPrefix("test-dispatch-reserved"),
+		WithSubjectTemplate("{prefix}.{client}.{source}"),
+		WithStatusReporting(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	// The published status event matches the dispatcher's wildcard but is not a notification
+	err = publisher.PublishNotification("test-client", "Test Title", "Test message", notification.TypeInfo, "system")
+	if err != nil {
+		t.Fatalf("Failed to publish notification: %v", err)
+	}
+
+	assert.Eventually(t, func() bool {
+		recorder.mu.Lock()
+		defer recorder.mu.Unlock()
+		return len(recorder.outcomes) == 1
+	}, 3*time.Second, 20*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	recorder.mu.Lock()
+	assert.Len(t, recorder.outcomes, 1)
+	recorder.mu.Unlock()
+	assert.Empty(t, errs)
+}
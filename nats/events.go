@@ -0,0 +1,186 @@
+package nats
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// ConnectionEventKind identifies a change in the lifecycle of a publisher's connection
+type ConnectionEventKind string
+
+// Connection event kinds
+const (
+	EventConnected    ConnectionEventKind = "connected"
+	EventDisconnected ConnectionEventKind = "disconnected"
+	EventReconnected  ConnectionEventKind = "reconnected"
+	EventClosed       ConnectionEventKind = "closed"
+	EventSlowConsumer ConnectionEventKind = "slow_consumer"
+	// EventLameDuck means the server is shutting down and the client will move to another server
+	EventLameDuck ConnectionEventKind = "lame_duck"
+)
+
+// ConnectionEvent describes a connection lifecycle change
+type ConnectionEvent struct {
+	Kind ConnectionEventKind
+	// URL is the server the connection is using, empty when disconnected
+	URL string
+	// Err is the cause of a disconnect, if any
+	Err error
+	// Subject is the subscription that fell behind, for slow consumer events
+	Subject string
+	Time    time.Time
+}
+
+// EventHandler is called for every connection event. Handlers run one at a time on the
+// client's callback goroutine and must not block.
+type EventHandler func(ConnectionEvent)
+
+// eventHub fans connection events out to handlers and channels
+type eventHub struct {
+	mu       sync.Mutex
+	handlers []registeredHandler
+	next     int
+	clock    func() time.Time
+}
+
+type registeredHandler struct {
+	id      int
+	handler EventHandler
+}
+
+func newEventHub(clock func() time.Time, handlers []EventHandler) *eventHub {
+	h := &eventHub{clock: clock}
+	for _, handler := range handlers {
+		h.add(handler)
+	}
+	return h
+}
+
+func (h *eventHub) add(handler EventHandler) func() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	id := h.next
+	h.next++
+	h.handlers = append(h.handlers, registeredHandler{id: id, handler: handler})
+
+	return func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		for i, r := range h.handlers {
+			if r.id == id {
+				h.handlers = append(h.handlers[:i:i], h.handlers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// emit calls the handlers in registration order, outside the lock so handlers can
+// remove themselves
+func (h *eventHub) emit(event ConnectionEvent) {
+	event.Time = h.clock()
+
+	h.mu.Lock()
+	handlers := h.handlers
+	h.mu.Unlock()
+	for _, r := range handlers {
+		r.handler(event)
+	}
+}
+
+// option chains the hub onto the handlers set by earlier options, so logging and
+// caller handlers keep working
+func (h *eventHub) option() nats.Option {
+	return func(o *nats.Options) error {
+		connected, disconnected, legacyDisconnected := o.ConnectedCB, o.DisconnectedErrCB, o.DisconnectedCB
+		reconnected, closed := o.ReconnectedCB, o.ClosedCB
+		lameDuck, asyncErr := o.LameDuckModeHandler, o.AsyncErrorCB
+
+		o.ConnectedCB = func(nc *nats.Conn) {
+			if connected != nil {
+				connected(nc)
+			}
+			h.emit(ConnectionEvent{Kind: EventConnected, URL: nc.ConnectedUrl()})
+		}
+		o.DisconnectedErrCB = func(nc *nats.Conn, err error) {
+			if disconnected != nil {
+				disconnected(nc, err)
+			} else if legacyDisconnected != nil {
+				// DisconnectedErrCB takes priority, so call the deprecated handler ourselves
+				legacyDisconnected(nc)
+			}
+			h.emit(ConnectionEvent{Kind: EventDisconnected, Err: err})
+		}
+		o.ReconnectedCB = func(nc *nats.Conn) {
+			if reconnected != nil {
+				reconnected(nc)
+			}
+			h.emit(ConnectionEvent{Kind: EventReconnected, URL: nc.ConnectedUrl()})
+		}
+		o.ClosedCB = func(nc *nats.Conn) {
+			if closed != nil {
+				closed(nc)
+			}
+			h.emit(ConnectionEvent{Kind: EventClosed, Err: nc.LastError()})
+		}
+		o.LameDuckModeHandler = func(nc *nats.Conn) {
+			if lameDuck != nil {
+				lameDuck(nc)
+			}
+			h.emit(ConnectionEvent{Kind: EventLameDuck, URL: nc.ConnectedUrl()})
+		}
+		o.AsyncErrorCB = func(nc *nats.Conn, sub *nats.Subscription, err error) {
+			if asyncErr != nil {
+				asyncErr(nc, sub, err)
+			}
+			if errors.Is(err, nats.ErrSlowConsumer) {
+				event := ConnectionEvent{Kind: EventSlowConsumer, URL: nc.ConnectedUrl(), Err: err}
+				if sub != nil {
+					event.Subject = sub.Subject
+				}
+				h.emit(event)
+			}
+		}
+		return nil
+	}
+}
+
+// OnEvent registers a handler for connection events and returns a function removing it.
+// Use WithEventHandler to also see the initial connect.
+func (p *Publisher) OnEvent(handler EventHandler) (remove func()) {
+	return p.events.add(handler)
+}
+
+// Events returns a channel receiving connection events and a function that stops delivery.
+// Events are dropped rather than blocking the connection when the channel is full.
+func (p *Publisher) Events(buffer int) (<-chan ConnectionEvent, func()) {
+	ch := make(chan ConnectionEvent, buffer)
+
+	var mu sync.Mutex
+	stopped := false
+	remove := p.events.add(func(event ConnectionEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		if stopped {
+			return
+		}
+		select {
+		case ch <- event:
+		default:
+			p.logger.Warn("dropped connection event", "kind", event.Kind)
+		}
+	})
+
+	return ch, func() {
+		remove()
+		mu.Lock()
+		defer mu.Unlock()
+		if !stopped {
+			stopped = true
+			close(ch)
+		}
+	}
+}
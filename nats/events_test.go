@@ -0,0 +1,88 @@
+package nats
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nextEvent(t *testing.T, events <-chan ConnectionEvent, kind ConnectionEventKind) ConnectionEvent {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event := <-events:
+			if event.Kind == kind {
+				return event
+			}
+		case <-timeout:
+			t.Fatalf("Timeout waiting for %s event", kind)
+		}
+	}
+}
+
+func TestConnectionEvents(t *testing.T) {
+	s, err := server.NewServer(&server.Options{
+		Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true,
+		LameDuckDuration: time.Second, LameDuckGracePeriod: 100 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	go s.Start()
+	require.True(t, s.ReadyForConnections(5*time.Second))
+	defer s.Shutdown()
+
+	events := make(chan ConnectionEvent, 16)
+	var rawDisconnects atomic.Int32
+	publisher, err := New(
+		WithURL(s.ClientURL()),
+		WithSubjectPrefix("test-events"),
+		WithJetStream(false),
+		WithEventHandler(func(event ConnectionEvent) { events <- event }),
+		// Handlers given as raw options still run
+		WithNATSOptions(nats.DisconnectErrHandler(func(*nats.Conn, error) { rawDisconnects.Add(1) })),
+	)
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	event := nextEvent(t, events, EventConnected)
+	assert.Equal(t, s.ClientURL(), event.URL)
+	assert.False(t, event.Time.IsZero())
+
+	channel, stop := publisher.Events(16)
+	removed := make(chan ConnectionEvent, 16)
+	remove := publisher.OnEvent(func(event ConnectionEvent) { removed <- event })
+	remove()
+
+	// A subscription on the publisher's connection that cannot keep up
+	block := make(chan struct{})
+	sub, err := publisher.nc.Subscribe("test-events.slow", func(*nats.Msg) { <-block })
+	require.NoError(t, err)
+	require.NoError(t, sub.SetPendingLimits(1, -1))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, publisher.nc.Publish("test-events.slow", []byte("x")))
+	}
+	event = nextEvent(t, channel, EventSlowConsumer)
+	assert.Equal(t, "test-events.slow", event.Subject)
+	assert.ErrorIs(t, event.Err, nats.ErrSlowConsumer)
+	close(block)
+
+	go s.LameDuckShutdown()
+	nextEvent(t, events, EventLameDuck)
+	event = nextEvent(t, channel, EventDisconnected)
+	assert.Empty(t, event.URL)
+	assert.Positive(t, rawDisconnects.Load())
+
+	stop()
+	stop()
+	_, open := <-channel
+	assert.False(t, open)
+
+	publisher.Close()
+	nextEvent(t, events, EventClosed)
+	assert.Empty(t, removed)
+}
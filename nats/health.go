@@ -0,0 +1,233 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultHealthCheckTimeout bounds health checks whose context has no deadline
+const DefaultHealthCheckTimeout = 5 * time.Second
+
+// pendingHighWater is the fill ratio above which a buffer degrades health
+const pendingHighWater = 0.8
+
+// Health check names
+const (
+	PublisherHealthName     = "nats_publisher"
+	PublisherPoolHealthName = "nats_publisher_pool"
+	DispatcherHealthName    = "nats_dispatcher"
+	ActionRouterHealthName  = "nats_action_router"
+	StatusTrackerHealthName = "nats_status_tracker"
+)
+
+// withHealthDeadline gives ctx the default health check deadline unless it has one
+func withHealthDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, DefaultHealthCheckTimeout)
+}
+
+// connectionHealth reports the state of a connection; a round trip to the server is
+// measured when it is connected
+func connectionHealth(ctx context.Context, name string, nc *nats.Conn) notification.HealthReport {
+	report := notification.HealthReport{Name: name, Status: notification.HealthUp, Details: map[string]any{}}
+	if nc == nil {
+		report.Status = notification.HealthDown
+		report.Error = "not connected"
+		return report
+	}
+
+	status := nc.Status()
+	report.Details["connection"] = status.String()
+	switch status {
+	case nats.CONNECTED:
+		report.Details["url"] = nc.ConnectedUrl()
+		ctx, cancel := withHealthDeadline(ctx)
+		defer cancel()
+		start := time.Now()
+		if err := nc.FlushWithContext(ctx); err != nil {
+			report.Status = notification.HealthDegraded
+			report.Error = "round trip failed: " + err.Error()
+		} else {
+			report.Details["rtt_ms"] = float64(time.Since(start).Microseconds()) / 1000
+		}
+	case nats.RECONNECTING, nats.CONNECTING:
+		// Publishes are buffered until the connection returns
+		report.Status = notification.HealthDegraded
+		report.Error = "connection is " + status.String()
+		if buffered, err := nc.Buffered(); err == nil {
+			report.Details["buffered_bytes"] = buffered
+			if size := nc.Opts.ReconnectBufSize; size > 0 && float64(buffered) > pendingHighWater*float64(size) {
+				report.Error = "reconnect buffer is nearly full"
+			}
+		}
+	default:
+		report.Status = notification.HealthDown
+		report.Error = "connection is " + status.String()
+	}
+	return report
+}
+
+// subscriptionHealth reports a connection and the pending buffer of a subscription on it
+func subscriptionHealth(ctx context.Context, name string, nc *nats.Conn, sub *nats.Subscription) notification.HealthReport {
+	report := connectionHealth(ctx, name, nc)
+	if report.Status == notification.HealthDown {
+		return report
+	}
+	if sub == nil || !sub.IsValid() {
+		report.Status = notification.HealthDown
+		report.Error = "not started"
+		return report
+	}
+
+	msgs, bytes, err := sub.Pending()
+	if err != nil {
+		report.Status = notification.HealthDown
+		report.Error = "subscription failed: " + err.Error()
+		return report
+	}
+	report.Details["pending_msgs"] = msgs
+	report.Details["pending_bytes"] = bytes
+	if dropped, err := sub.Dropped(); err == nil {
+		report.Details["dropped_msgs"] = dropped
+	}
+
+	maxMsgs, maxBytes, err := sub.PendingLimits()
+	if err == nil && (overHighWater(msgs, maxMsgs) || overHighWater(bytes, maxBytes)) {
+		report.Status = report.Status.Worse(notification.HealthDegraded)
+		report.Error = "pending buffer is nearly full"
+	}
+	return report
+}
+
+// overHighWater reports whether pending exceeds the high water mark of limit; a
+// non-positive limit is unlimited
+func overHighWater(pending, limit int) bool {
+	return limit > 0 && float64(pending) > pendingHighWater*float64(limit)
+}
+
+// CheckHealth reports the publisher's connection status, round trip time and the
+// size of its reconnect buffer
+func (p *Publisher) CheckHealth(ctx context.Context) notification.HealthReport {
+	if p.isClosed() {
+		return notification.HealthReport{Name: PublisherHealthName, Status: notification.HealthDown, Error: ErrPublisherClosed.Error()}
+	}
+	report := connectionHealth(ctx, PublisherHealthName, p.nc)
+	if p.spool != nil {
+		report.Details["spooled_bytes"] = p.spool.store.Stats().Bytes
+	}
+	return report
+}
+
+// StreamHealthCheck returns a check of a JetStream stream reachable through the publisher
+func (p *Publisher) StreamHealthCheck(stream string) notification.HealthChecker {
+	return NewStreamHealthCheck(p.js, stream)
+}
+
+// CheckHealth reports the dispatcher's connection and subscription
+func (d *Dispatcher) CheckHealth(ctx context.Context) notification.HealthReport {
+	d.mu.Lock()
+	sub := d.sub
+	d.mu.Unlock()
+	return subscriptionHealth(ctx, DispatcherHealthName, d.nc, sub)
+}
+
+// CheckHealth reports the action router's connection and consumer subscription
+func (r *ActionRouter) CheckHealth(ctx context.Context) notification.HealthReport {
+	r.mu.RLock()
+	sub := r.sub
+	r.mu.RUnlock()
+	return subscriptionHealth(ctx, ActionRouterHealthName, r.nc, sub)
+}
+
+// CheckHealth reports the status tracker's connection and subscription
+func (t *StatusTracker) CheckHealth(ctx context.Context) notification.HealthReport {
+	t.mu.Lock()
+	sub := t.sub
+	t.mu.Unlock()
+	return subscriptionHealth(ctx, StatusTrackerHealthName, t.nc, sub)
+}
+
+// StreamHealthCheck checks that a JetStream stream is reachable
+type StreamHealthCheck struct {
+	js     nats.JetStreamContext
+	stream string
+}
+
+// NewStreamHealthCheck creates a check of the named stream
+func NewStreamHealthCheck(js nats.JetStreamContext, stream string) *StreamHealthCheck {
+	return &StreamHealthCheck{js: js, stream: stream}
+}
+
+// CheckHealth looks the stream up and reports its size and consumers
+func (c *StreamHealthCheck) CheckHealth(ctx context.Context) notification.HealthReport {
+	report := notification.HealthReport{Name: "jetstream_stream:" + c.stream, Status: notification.HealthUp}
+	if c.js == nil {
+		report.Status = notification.HealthDown
+		report.Error = "JetStream is disabled"
+		return report
+	}
+
+	ctx, cancel := withHealthDeadline(ctx)
+	defer cancel()
+	info, err := c.js.StreamInfo(c.stream, nats.Context(ctx))
+	if err != nil {
+		report.Status = notification.HealthDown
+		report.Error = "stream unreachable: " + err.Error()
+		return report
+	}
+
+	report.Details = map[string]any{
+		"messages":  info.State.Msgs,
+		"bytes":     info.State.Bytes,
+		"consumers": info.State.Consumers,
+		"last_seq":  info.State.LastSeq,
+	}
+	if info.Config.MaxBytes > 0 && float64(info.State.Bytes) > pendingHighWater*float64(info.Config.MaxBytes) {
+		report.Status = notification.HealthDegraded
+		report.Error = "stream is nearly full"
+	}
+	return report
+}
+
+// healthResponse is the body written by HealthHandler
+type healthResponse struct {
+	Status notification.HealthStatus   `json:"status"`
+	Checks []notification.HealthReport `json:"checks"`
+}
+
+// HealthHandler runs the checks concurrently and writes their reports as JSON. It answers
+// 503 when any check is down and 200 otherwise, so degraded components stay in service.
+func HealthHandler(checkers ...notification.HealthChecker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := withHealthDeadline(r.Context())
+		defer cancel()
+
+		response := healthResponse{Status: notification.HealthUp, Checks: make([]notification.HealthReport, len(checkers))}
+		var wg sync.WaitGroup
+		for i, checker := range checkers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				response.Checks[i] = checker.CheckHealth(ctx)
+			}()
+		}
+		wg.Wait()
+		for _, report := range response.Checks {
+			response.Status = response.Status.Worse(report.Status)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if response.Status == notification.HealthDown {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	})
+}
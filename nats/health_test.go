@@ -0,0 +1,118 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serveHealth(t *testing.T, checkers ...notification.HealthChecker) (int, healthResponse) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	HealthHandler(checkers...).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, "application/json", recorder.Header().Get("Content-Type"))
+
+	var response healthResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+	return recorder.Code, response
+}
+
+func TestHealthHandler(t *testing.T) {
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true, JetStream: true, StoreDir: t.TempDir()})
+	require.NoError(t, err)
+	go s.Start()
+	require.True(t, s.ReadyForConnections(5*time.Second))
+	defer s.Shutdown()
+
+	publisher, err := New(WithURL(s.ClientURL()), WithSubjectPrefix("test-health"))
+	require.NoError(t, err)
+	defer publisher.Close()
+	_, err = publisher.js.AddStream(&nats.StreamConfig{Name: "HEALTH", Subjects: []string{"test-health.stream.>"}})
+	require.NoError(t, err)
+
+	report := publisher.CheckHealth(context.Background())
+	assert.Equal(t, PublisherHealthName, report.Name)
+	assert.Equal(t, notification.HealthUp, report.Status)
+	assert.Equal(t, s.ClientURL(), report.Details["url"])
+	assert.Contains(t, report.Details, "rtt_ms")
+
+	code, response := serveHealth(t, publisher, publisher.StreamHealthCheck("HEALTH"))
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, notification.HealthUp, response.Status)
+	require.Len(t, response.Checks, 2)
+	assert.Equal(t, "jetstream_stream:HEALTH", response.Checks[1].Name)
+	assert.Contains(t, response.Checks[1].Details, "messages")
+
+	// Degraded components keep the service in rotation
+	degraded := notification.HealthCheckFunc(func(context.Context) notification.HealthReport {
+		return notification.HealthReport{Name: "cache", Status: notification.HealthDegraded}
+	})
+	code, response = serveHealth(t, publisher, degraded)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, notification.HealthDegraded, response.Status)
+
+	code, response = serveHealth(t, publisher, degraded, publisher.StreamHealthCheck("MISSING"))
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, notification.HealthDown, response.Status)
+	assert.Contains(t, response.Checks[2].Error, "stream unreachable")
+
+	publisher.Close()
+	report = publisher.CheckHealth(context.Background())
+	assert.Equal(t, notification.HealthDown, report.Status)
+	assert.Equal(t, "publisher closed", report.Error)
+
+	report = NewStreamHealthCheck(nil, "HEALTH").CheckHealth(context.Background())
+	assert.Equal(t, notification.HealthDown, report.Status)
+}
+
+func TestSubscriberHealth(t *testing.T) {
+	s := startServer(t)
+
+	dispatcher, err := NewDispatcher(s.ClientURL(), "test-health", DispatcherConfig{})
+	require.NoError(t, err)
+	defer dispatcher.Close()
+
+	report := dispatcher.CheckHealth(context.Background())
+	assert.Equal(t, DispatcherHealthName, report.Name)
+	assert.Equal(t, notification.HealthDown, report.Status)
+	assert.Equal(t, "not started", report.Error)
+
+	require.NoError(t, dispatcher.Start())
+	report = dispatcher.CheckHealth(context.Background())
+	assert.Equal(t, notification.HealthUp, report.Status)
+	assert.Contains(t, report.Details, "pending_msgs")
+
+	// A subscription that stopped keeping up is degraded
+	nc, err := nats.Connect(s.ClientURL())
+	require.NoError(t, err)
+	defer nc.Close()
+	block := make(chan struct{})
+	defer close(block)
+	sub, err := nc.Subscribe("test-health.slow", func(*nats.Msg) { <-block })
+	require.NoError(t, err)
+	require.NoError(t, sub.SetPendingLimits(10, -1))
+	for i := 0; i < 10; i++ {
+		require.NoError(t, nc.Publish("test-health.slow", []byte("x")))
+	}
+	require.Eventually(t, func() bool {
+		msgs, _, _ := sub.Pending()
+		return msgs >= 9
+	}, 2*time.Second, 10*time.Millisecond)
+
+	report = subscriptionHealth(context.Background(), "slow", nc, sub)
+	assert.Equal(t, notification.HealthDegraded, report.Status)
+	assert.Equal(t, "pending buffer is nearly full", report.Error)
+
+	dispatcher.Close()
+	report = dispatcher.CheckHealth(context.Background())
+	assert.Equal(t, notification.HealthDown, report.Status)
+}
@@ -0,0 +1,487 @@
+package nats
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/MyWeHub/notification-sdk/internal/natsutil"
+	"github.com/MyWeHub/notification-sdk/internal/utils"
+	"github.com/MyWeHub/notification-sdk/internal/validation"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+)
+
+// DefaultSubjectPrefix is the subject prefix of publishers created without WithSubjectPrefix
+const DefaultSubjectPrefix = "notifications"
+
+// DefaultConnectRetries is how many times New tries to connect before giving up
+const DefaultConnectRetries = 3
+
+// Codec encodes notifications for the wire. Publishers and dispatchers must use the same codec.
+type Codec interface {
+	Encode(n *notification.Notification) ([]byte, error)
+	Decode(data []byte) (*notification.Notification, error)
+}
+
+// JSONCodec is the default codec, encoding notifications as JSON
+type JSONCodec struct{}
+
+// Encode marshals a notification to JSON
+func (JSONCodec) Encode(n *notification.Notification) ([]byte, error) {
+	return utils.MarshalNotification(n)
+}
+
+// Decode unmarshals a notification from JSON
+func (JSONCodec) Decode(data []byte) (*notification.Notification, error) {
+	return utils.UnmarshalNotification(data)
+}
+
+// PublishFunc publishes a notification on a subject
+type PublishFunc func(ctx context.Context, subject string, notif *notification.Notification) error
+
+// Middleware wraps the publishing of every notification, e.g. for tracing or enrichment.
+// Middlewares run in the order they were given, after validation and authorization.
+type Middleware func(next PublishFunc) PublishFunc
+
+// Metrics receives publisher measurements
+type Metrics interface {
+	// ObservePublish is called after every publish attempt, including those written to
+	// the spool; err is nil on success
+	ObservePublish(subject string, duration time.Duration, err error)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObservePublish(string, time.Duration, error) {}
+
+// Option configures a publisher created with New
+type Option func(*publisherOptions)
+
+type publisherOptions struct {
+	urls            []string
+	subjectPrefix   string
+	subjectTemplate string
+	legacy          bool
+	reportStatus    bool
+	templates       notification.TemplateRendererPort
+	directory       notification.UserDirectoryPort
+	audiences       notification.AudienceResolverPort
+	topics          notification.TopicStorePort
+	preferences     notification.UserPreferencesPort
+	authorizer      notification.AuthorizerPort
+	principal       notification.Principal
+	connectPolicy   RetryPolicy
+	publishPolicy   RetryPolicy
+	lazy            bool
+	disconnected    DisconnectedPolicy
+	reconnectBuf    int
+	jetStream       bool
+	natsOptions     []nats.Option
+	rawOptions      bool
+	security        *SecurityConfig
+	codec           Codec
+	middleware      []Middleware
+	eventHandlers   []EventHandler
+	spool           *SpoolConfig
+	events          *eventHub
+	logger          *slog.Logger
+	metrics         Metrics
+	clock           func() time.Time
+	newID           func() string
+}
+
+func defaultPublisherOptions() *publisherOptions {
+	return &publisherOptions{
+		urls:            []string{nats.DefaultURL},
+		subjectPrefix:   DefaultSubjectPrefix,
+		subjectTemplate: natsutil.DefaultSubjectTemplate,
+		connectPolicy:   DefaultRetryPolicy(),
+		publishPolicy:   NoRetry(),
+		jetStream:       true,
+		codec:           JSONCodec{},
+		logger:          slog.New(slog.NewTextHandler(io.Discard, nil)),
+		metrics:         nopMetrics{},
+		clock:           utils.UTCNow,
+		newID:           func() string { return uuid.New().String() },
+	}
+}
+
+// WithURL sets the servers to connect to; several URLs form a cluster seed list
+func WithURL(urls ...string) Option {
+	return func(o *publisherOptions) {
+		o.urls = urls
+	}
+}
+
+// WithSubjectPrefix sets the prefix of every subject the publisher publishes on
+func WithSubjectPrefix(subjectPrefix string) Option {
+	return func(o *publisherOptions) {
+		o.subjectPrefix = subjectPrefix
+	}
+}
+
+// WithConnectRetries sets how many times to try connecting before New fails
+func WithConnectRetries(attempts int) Option {
+	return func(o *publisherOptions) {
+		o.connectPolicy.MaxAttempts = attempts
+	}
+}
+
+// WithRetryPolicy sets how connecting is retried; defaults to DefaultRetryPolicy
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *publisherOptions) {
+		o.connectPolicy = policy
+	}
+}
+
+// WithPublishRetryPolicy sets how failed publishes are retried; defaults to NoRetry.
+// Errors retrying cannot fix, such as a closed connection, are returned at once.
+func WithPublishRetryPolicy(policy RetryPolicy) Option {
+	return func(o *publisherOptions) {
+		o.publishPolicy = policy
+	}
+}
+
+// WithJetStream enables or disables JetStream. Without it action responses,
+// attachments and the KV topic store are unavailable.
+func WithJetStream(enabled bool) Option {
+	return func(o *publisherOptions) {
+		o.jetStream = enabled
+	}
+}
+
+// WithLazyConnect returns the publisher immediately and connects in the background, so a
+// service can start while NATS is down. Publishes made before the first connect follow
+// the DisconnectedPolicy; Ready and ReadinessHandler report when the publisher is connected.
+func WithLazyConnect() Option {
+	return func(o *publisherOptions) {
+		o.lazy = true
+	}
+}
+
+// WithDisconnectedPolicy decides what happens to publishes while the publisher is not
+// connected; defaults to BufferWhileDisconnected
+func WithDisconnectedPolicy(policy DisconnectedPolicy) Option {
+	return func(o *publisherOptions) {
+		o.disconnected = policy
+	}
+}
+
+// WithReconnectBufferSize sets how many bytes of publishes are buffered while disconnected
+func WithReconnectBufferSize(bytes int) Option {
+	return func(o *publisherOptions) {
+		o.reconnectBuf = bytes
+	}
+}
+
+// WithNATSOptions adds raw NATS connection options, applied after the defaults
+func WithNATSOptions(opts ...nats.Option) Option {
+	return func(o *publisherOptions) {
+		o.natsOptions = append(o.natsOptions, opts...)
+	}
+}
+
+// withoutDefaultConnectOptions connects with the given NATS options only, as
+// NewPublisherWithOptions always has
+func withoutDefaultConnectOptions() Option {
+	return func(o *publisherOptions) {
+		o.rawOptions = true
+	}
+}
+
+// WithSecurity authenticates with a typed security configuration
+func WithSecurity(security SecurityConfig) Option {
+	return func(o *publisherOptions) {
+		o.security = &security
+	}
+}
+
+// WithCodec sets the codec notifications are encoded with; defaults to JSONCodec
+func WithCodec(codec Codec) Option {
+	return func(o *publisherOptions) {
+		o.codec = codec
+	}
+}
+
+// WithMiddleware appends publish middlewares
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(o *publisherOptions) {
+		o.middleware = append(o.middleware, middleware...)
+	}
+}
+
+// WithEventHandler registers connection event handlers before connecting, so they also
+// see the initial connect
+func WithEventHandler(handlers ...EventHandler) Option {
+	return func(o *publisherOptions) {
+		o.eventHandlers = append(o.eventHandlers, handlers...)
+	}
+}
+
+// WithLogger sets the logger for connection events and publish failures; logs are
+// discarded by default
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *publisherOptions) {
+		o.logger = logger
+	}
+}
+
+// WithMetrics sets the receiver of publisher measurements
+func WithMetrics(metrics Metrics) Option {
+	return func(o *publisherOptions) {
+		o.metrics = metrics
+	}
+}
+
+// WithClock sets the clock CreatedAt and timestamps are taken from
+func WithClock(clock func() time.Time) Option {
+	return func(o *publisherOptions) {
+		o.clock = clock
+	}
+}
+
+// WithIDGenerator sets the generator of notification IDs; defaults to random UUIDs
+func WithIDGenerator(newID func() string) Option {
+	return func(o *publisherOptions) {
+		o.newID = newID
+	}
+}
+
+func (o *publisherOptions) validate() error {
+	if len(o.urls) == 0 {
+		return notification.NewError(notification.InvalidArguments, "at least one NATS URL is required")
+	}
+	for _, url := range o.urls {
+		if url == "" {
+			return notification.NewError(notification.InvalidArguments, "NATS URL cannot be empty")
+		}
+	}
+	// Checked once here rather than failing every publish
+	if err := natsutil.ValidatePublishSubject(o.subjectPrefix); err != nil {
+		return err
+	}
+	if _, err := natsutil.NewSubjectScheme(o.subjectTemplate, o.subjectPrefix); err != nil {
+		return err
+	}
+	if o.authorizer != nil {
+		if err := validation.ValidateSource(o.principal.Source); err != nil {
+			return err
+		}
+	}
+	if o.connectPolicy.MaxAttempts < 1 {
+		return notification.NewError(notification.InvalidArguments, "connect retries must be at least 1")
+	}
+	if err := o.connectPolicy.Validate(); err != nil {
+		return err
+	}
+	if err := o.publishPolicy.Validate(); err != nil {
+		return err
+	}
+	if o.disconnected != BufferWhileDisconnected && o.disconnected != RejectWhileDisconnected {
+		return notification.NewError(notification.InvalidArguments, "unknown disconnected policy")
+	}
+	if o.reconnectBuf < 0 {
+		return notification.NewError(notification.InvalidArguments, "reconnect buffer size cannot be negative")
+	}
+	if o.codec == nil {
+		return notification.NewError(notification.InvalidArguments, "codec cannot be nil")
+	}
+	if o.logger == nil {
+		return notification.NewError(notification.InvalidArguments, "logger cannot be nil")
+	}
+	if o.metrics == nil {
+		return notification.NewError(notification.InvalidArguments, "metrics cannot be nil")
+	}
+	if o.clock == nil {
+		return notification.NewError(notification.InvalidArguments, "clock cannot be nil")
+	}
+	if o.newID == nil {
+		return notification.NewError(notification.InvalidArguments, "ID generator cannot be nil")
+	}
+	for _, m := range o.middleware {
+		if m == nil {
+			return notification.NewError(notification.InvalidArguments, "middleware cannot be nil")
+		}
+	}
+	if o.spool != nil {
+		if err := o.spool.validate(); err != nil {
+			return err
+		}
+	}
+	for _, h := range o.eventHandlers {
+		if h == nil {
+			return notification.NewError(notification.InvalidArguments, "event handler cannot be nil")
+		}
+	}
+	return nil
+}
+
+// connectOptions assembles the NATS options: defaults, logging handlers, caller options
+// and security, so security settings cannot be overridden by accident. Connection events
+// are chained onto whichever handlers those options set.
+func (o *publisherOptions) connectOptions() ([]nats.Option, error) {
+	var opts []nats.Option
+	if !o.rawOptions {
+		opts = append(opts, natsutil.DefaultConnectOptions()...)
+		opts = append(opts, o.loggingHandlers()...)
+	}
+	if o.reconnectBuf > 0 {
+		opts = append(opts, nats.ReconnectBufSize(o.reconnectBuf))
+	}
+	opts = append(opts, o.natsOptions...)
+	if o.lazy {
+		opts = append(opts, nats.RetryOnFailedConnect(true))
+	}
+
+	securityOpts, err := o.security.Options()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, securityOpts...)
+	return append(opts, o.events.option()), nil
+}
+
+func (o *publisherOptions) loggingHandlers() []nats.Option {
+	logger := o.logger
+	return []nats.Option{
+		nats.DisconnectErrHandler(func(nc *nats.Conn, err error) {
+			logger.Warn("disconnected from NATS", "error", err)
+		}),
+		nats.ReconnectHandler(func(nc *nats.Conn) {
+			logger.Info("reconnected to NATS", "url", nc.ConnectedUrl())
+		}),
+		nats.ErrorHandler(func(nc *nats.Conn, sub *nats.Subscription, err error) {
+			logger.Error("NATS error", "error", err)
+		}),
+	}
+}
+
+// New creates a publisher configured with functional options. Without options it
+// connects to nats.DefaultURL and publishes under DefaultSubjectPrefix.
+func New(opts ...Option) (*Publisher, error) {
+	return NewWithContext(context.Background(), opts...)
+}
+
+// NewWithContext is New with a context that cancels connect retries
+func NewWithContext(ctx context.Context, opts ...Option) (*Publisher, error) {
+	o := defaultPublisherOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	o.events = newEventHub(o.clock, o.eventHandlers)
+
+	connectOpts, err := o.connectOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	var ps *publisherSpool
+	if o.spool != nil {
+		if ps, err = openSpool(o.spool); err != nil {
+			return nil, err
+		}
+	}
+
+	policy := o.connectPolicy
+	if o.lazy {
+		// The client keeps retrying in the background, so one attempt returns at once
+		policy = NoRetry()
+	}
+	nc, err := natsutil.ConnectWithPolicy(ctx, strings.Join(o.urls, ","), policy, connectOpts...)
+	if err != nil {
+		if ps != nil {
+			_ = ps.store.Close()
+		}
+		return nil, err
+	}
+
+	var js nats.JetStreamContext
+	if o.jetStream {
+		js, err = natsutil.CreateJetStreamContext(nc)
+		if err != nil {
+			nc.Close() // Clean up connection on error
+			if ps != nil {
+				_ = ps.store.Close()
+			}
+			return nil, err
+		}
+	}
+
+	p := newPublisher(nc, js, o)
+	p.ownsConn = true
+	if ps != nil {
+		p.spool = ps
+		p.startSpool()
+	}
+	return p, nil
+}
+
+// NewFromConn creates a publisher on an existing connection, e.g. one a service already
+// uses for other purposes or one to an embedded server in tests. The publisher does not
+// own the connection: Close and Shutdown leave it open. A nil js creates a JetStream
+// context unless WithJetStream(false) is given.
+//
+// Connection events are not observed, and options that configure dialing are rejected,
+// since the connection is already set up; WithURL and the connect retry policy are unused.
+func NewFromConn(nc *nats.Conn, js nats.JetStreamContext, opts ...Option) (*Publisher, error) {
+	if nc == nil {
+		return nil, notification.NewError(notification.InvalidArguments, "NATS connection cannot be nil")
+	}
+	if nc.IsClosed() {
+		return nil, notification.NewError(notification.InvalidArguments, "NATS connection is closed")
+	}
+
+	o := defaultPublisherOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+	if err := o.validate(); err != nil {
+		return nil, err
+	}
+	if err := o.validateShared(); err != nil {
+		return nil, err
+	}
+	o.events = newEventHub(o.clock, nil)
+
+	if !o.jetStream {
+		js = nil
+	} else if js == nil {
+		var err error
+		js, err = natsutil.CreateJetStreamContext(nc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newPublisher(nc, js, o), nil
+}
+
+// validateShared rejects options that only apply to connections the publisher dials
+func (o *publisherOptions) validateShared() error {
+	var option string
+	switch {
+	case o.lazy:
+		option = "WithLazyConnect"
+	case o.reconnectBuf > 0:
+		option = "WithReconnectBufferSize"
+	case len(o.natsOptions) > 0 || o.rawOptions:
+		option = "WithNATSOptions"
+	case o.security != nil:
+		option = "WithSecurity"
+	case len(o.eventHandlers) > 0:
+		option = "WithEventHandler"
+	case o.spool != nil:
+		option = "WithSpool"
+	default:
+		return nil
+	}
+	return notification.NewError(notification.InvalidArguments, option+" cannot be used with an existing connection")
+}
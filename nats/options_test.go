@@ -0,0 +1,240 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type allowAll struct{}
+
+func (allowAll) Authorize(context.Context, *notification.Notification) error { return nil }
+
+type recordingMetrics struct {
+	mu       sync.Mutex
+	subjects []string
+}
+
+func (m *recordingMetrics) ObservePublish(subject string, _ time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err == nil {
+		m.subjects = append(m.subjects, subject)
+	}
+}
+
+// markedCodec prefixes the JSON encoding with a marker so tests can tell it was used
+type markedCodec struct{ JSONCodec }
+
+func (c markedCodec) Encode(n *notification.Notification) ([]byte, error) {
+	data, err := c.JSONCodec.Encode(n)
+	return append([]byte("v1:"), data...), err
+}
+
+func TestNewInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  Option
+	}{
+		{"no URL", WithURL()},
+		{"empty URL", WithURL("")},
+		{"empty subject prefix", WithSubjectPrefix("")},
+		{"wildcard subject prefix", WithSubjectPrefix("notifications.*")},
+		{"no retries", WithConnectRetries(0)},
+		{"nil codec", WithCodec(nil)},
+		{"nil logger", WithLogger(nil)},
+		{"nil metrics", WithMetrics(nil)},
+		{"nil clock", WithClock(nil)},
+		{"nil ID generator", WithIDGenerator(nil)},
+		{"nil middleware", WithMiddleware(nil)},
+		{"nil event handler", WithEventHandler(nil)},
+		{"spool without directory", WithSpool(SpoolConfig{})},
+		{"invalid security", WithSecurity(SecurityConfig{Username: "u"})},
+		{"invalid subject template", WithSubjectTemplate("{prefix}.{user}")},
+		{"authorizer without principal", WithAuthorizer(allowAll{}, notification.Principal{})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.opt)
+			if assert.Error(t, err) {
+				assert.EqualValues(t, notification.InvalidArguments, err.(*notification.Error).Code)
+			}
+		})
+	}
+}
+
+func TestNewWithOptions(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	metrics := &recordingMetrics{}
+	var order []string
+	trace := func(name string) Middleware {
+		return func(next PublishFunc) PublishFunc {
+			return func(ctx context.Context, subject string, notif *notification.Notification) error {
+				order = append(order, name)
+				return next(ctx, subject, notif)
+			}
+		}
+	}
+	reject := func(next PublishFunc) PublishFunc {
+		return func(ctx context.Context, subject string, notif *notification.Notification) error {
+			if notif.ClientID == "blocked" {
+				return errors.New("blocked by middleware")
+			}
+			return next(ctx, subject, notif)
+		}
+	}
+
+	publisher, err := New(
+		WithURL("nats://127.0.0.1:1", nats.DefaultURL),
+		WithSubjectPrefix("test-options"),
+		WithConnectRetries(1),
+		WithJetStream(false),
+		WithCodec(markedCodec{}),
+		WithMiddleware(trace("first"), trace("second"), reject),
+		WithMetrics(metrics),
+		WithClock(func() time.Time { return now }),
+		WithIDGenerator(func() string { return "fixed-id" }),
+		WithNATSOptions(nats.Name("options-test"), nats.DontRandomize()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	ch := make(chan *nats.Msg, 2)
+	subscription, err := nc.ChanSubscribe("test-options.*", ch)
+	if err != nil {
+		t.Fatalf("Failed to subscribe to NATS: %v", err)
+	}
+	defer subscription.Unsubscribe()
+	if err := nc.Flush(); err != nil {
+		t.Fatalf("Failed to flush connection: %v", err)
+	}
+
+	assert.NoError(t, publisher.PublishNotification("client-1", "Title", "Message", notification.TypeInfo, "system"))
+	assert.Error(t, publisher.PublishNotification("blocked", "Title", "Message", notification.TypeInfo, "system"))
+
+	select {
+	case msg := <-ch:
+		assert.Equal(t, "test-options.client-1", msg.Subject)
+		assert.Equal(t, "v1:", string(msg.Data[:3]))
+		var n notification.Notification
+		assert.NoError(t, json.Unmarshal(msg.Data[3:], &n))
+		assert.Equal(t, "fixed-id", n.ID)
+		assert.True(t, now.Equal(n.CreatedAt))
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for notification")
+	}
+
+	assert.Equal(t, []string{"first", "second", "first", "second"}, order)
+	assert.Equal(t, []string{"test-options.client-1"}, metrics.subjects)
+
+	err = publisher.PublishActionResponse(&notification.ActionResponse{NotificationID: "n-1", ActionID: "approve", UserID: "u-1", Source: "billing"})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "JetStream is disabled")
+	}
+}
+
+func TestRetryOptions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewWithContext(ctx, WithURL("nats://127.0.0.1:1"), WithRetryPolicy(RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour}))
+	assert.ErrorContains(t, err, context.Canceled.Error())
+
+	_, err = New(WithPublishRetryPolicy(RetryPolicy{MaxAttempts: 2, Jitter: 3}))
+	assert.Error(t, err)
+
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	publisher, err := New(WithSubjectPrefix("test-retry"), WithPublishRetryPolicy(RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour}))
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	assert.NoError(t, publisher.PublishNotification("client-1", "Title", "Message", notification.TypeInfo, "system"))
+
+	// A closed connection cannot recover, so the error is returned without waiting
+	publisher.nc.Close()
+	start := time.Now()
+	err = publisher.PublishNotification("client-1", "Title", "Message", notification.TypeInfo, "system")
+	assert.ErrorContains(t, err, nats.ErrConnectionClosed.Error())
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestNewFromConn(t *testing.T) {
+	s := startServer(t)
+
+	_, err := NewFromConn(nil, nil)
+	assert.EqualValues(t, notification.InvalidArguments, err.(*notification.Error).Code)
+
+	nc, err := nats.Connect(s.ClientURL())
+	require.NoError(t, err)
+	defer nc.Close()
+
+	for _, opt := range []Option{WithLazyConnect(), WithSecurity(SecurityConfig{Token: "secret"}), WithNATSOptions(nats.Name("x")), WithEventHandler(func(ConnectionEvent) {}), WithSpool(SpoolConfig{Dir: t.TempDir()})} {
+		_, err := NewFromConn(nc, nil, opt)
+		assert.ErrorContains(t, err, "cannot be used with an existing connection")
+	}
+
+	sub, err := nc.SubscribeSync("test-shared.*")
+	require.NoError(t, err)
+
+	publisher, err := NewFromConn(nc, nil, WithSubjectPrefix("test-shared"))
+	require.NoError(t, err)
+	assert.NotNil(t, publisher.js)
+	require.NoError(t, publisher.PublishNotification("client-1", "Shared", "Message", notification.TypeInfo, "system"))
+	require.NoError(t, publisher.Shutdown(context.Background()))
+
+	// The connection outlives the publisher
+	assert.True(t, nc.IsConnected())
+	msg, err := sub.NextMsg(2 * time.Second)
+	require.NoError(t, err)
+	assert.Contains(t, string(msg.Data), "Shared")
+	assert.ErrorIs(t, publisher.PublishNotification("client-1", "Late", "Message", notification.TypeInfo, "system"), ErrPublisherClosed)
+
+	// Shutdown does not wait for async publishes other code made on a shared JetStream context
+	js, err := nc.JetStream()
+	require.NoError(t, err)
+	silent, err := nc.SubscribeSync("test-shared-async.*")
+	require.NoError(t, err)
+	defer silent.Unsubscribe()
+	_, err = js.PublishAsync("test-shared-async.1", []byte("never acked"))
+	require.NoError(t, err)
+	require.Equal(t, 1, js.PublishAsyncPending())
+	sharedJS, err := NewFromConn(nc, js)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	require.NoError(t, sharedJS.Shutdown(ctx))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 1, js.PublishAsyncPending())
+
+	withoutJetStream, err := NewFromConn(nc, nil, WithJetStream(false))
+	require.NoError(t, err)
+	assert.Nil(t, withoutJetStream.js)
+	require.NoError(t, withoutJetStream.Close())
+	assert.True(t, nc.IsConnected())
+
+	nc.Close()
+	_, err = NewFromConn(nc, nil)
+	assert.ErrorContains(t, err, "closed")
+}
@@ -0,0 +1,145 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+// PoolStrategy decides which connection of a pool publishes a notification
+type PoolStrategy int
+
+const (
+	// RoundRobin spreads publishes evenly over the connections. Notifications to the
+	// same client may be delivered out of order.
+	RoundRobin PoolStrategy = iota
+	// HashClientID always publishes a client's notifications on the same connection,
+	// preserving their order
+	HashClientID
+)
+
+// PublisherPool spreads publishes over several connections opened with the same options.
+// Whether that is faster than a single connection depends on the workload; measure it
+// with BenchmarkPublish.
+type PublisherPool struct {
+	publishers []*Publisher
+	strategy   PoolStrategy
+	next       atomic.Uint64
+}
+
+var _ notification.PublisherPort = (*PublisherPool)(nil)
+
+// NewPublisherPool creates size publishers with the same options. With WithSpool each
+// publisher spools to its own subdirectory of SpoolConfig.Dir, named after its index.
+func NewPublisherPool(size int, strategy PoolStrategy, opts ...Option) (*PublisherPool, error) {
+	if size < 1 {
+		return nil, notification.NewError(notification.InvalidArguments, "pool size must be at least 1")
+	}
+	if strategy != RoundRobin && strategy != HashClientID {
+		return nil, notification.NewError(notification.InvalidArguments, "unknown pool strategy")
+	}
+
+	o := defaultPublisherOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	pool := &PublisherPool{publishers: make([]*Publisher, 0, size), strategy: strategy}
+	for i := 0; i < size; i++ {
+		publisherOpts := opts
+		if o.spool != nil {
+			// Publishers must not share a spool directory
+			config := *o.spool
+			config.Dir = filepath.Join(config.Dir, strconv.Itoa(i))
+			publisherOpts = append(opts[:len(opts):len(opts)], WithSpool(config))
+		}
+		publisher, err := New(publisherOpts...)
+		if err != nil {
+			pool.Close() // Clean up the connections already made
+			return nil, err
+		}
+		pool.publishers = append(pool.publishers, publisher)
+	}
+	return pool, nil
+}
+
+// Size returns the number of connections in the pool
+func (p *PublisherPool) Size() int {
+	return len(p.publishers)
+}
+
+// Publishers returns the pooled publishers, e.g. to inspect their status
+func (p *PublisherPool) Publishers() []*Publisher {
+	return append([]*Publisher(nil), p.publishers...)
+}
+
+// pick selects the publisher for a notification to clientID
+func (p *PublisherPool) pick(clientID string) *Publisher {
+	n := uint64(len(p.publishers))
+	if p.strategy == HashClientID {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(clientID))
+		return p.publishers[h.Sum64()%n]
+	}
+	return p.publishers[(p.next.Add(1)-1)%n]
+}
+
+// PublishNotification publishes a notification on one of the pooled connections
+func (p *PublisherPool) PublishNotification(clientID string, title string, message string, notificationType notification.NotificationType, source string) error {
+	return p.pick(clientID).PublishNotification(clientID, title, message, notificationType, source)
+}
+
+// PublishCustomNotification publishes a custom notification on one of the pooled connections
+func (p *PublisherPool) PublishCustomNotification(clientID string, notif *notification.Notification) error {
+	// Hash on the client the subject is built from
+	if notif != nil && notif.ClientID != "" {
+		return p.pick(notif.ClientID).PublishCustomNotification(clientID, notif)
+	}
+	return p.pick(clientID).PublishCustomNotification(clientID, notif)
+}
+
+// Close closes every connection of the pool at once
+func (p *PublisherPool) Close() error {
+	for _, publisher := range p.publishers {
+		publisher.Close()
+	}
+	return nil
+}
+
+// Shutdown drains every connection of the pool concurrently and returns the errors of
+// every connection that failed, joined
+func (p *PublisherPool) Shutdown(ctx context.Context) error {
+	errs := make([]error, len(p.publishers))
+	var wg sync.WaitGroup
+	for i, publisher := range p.publishers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = publisher.Shutdown(ctx)
+		}()
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// CheckHealth reports the worst health of the pooled connections
+func (p *PublisherPool) CheckHealth(ctx context.Context) notification.HealthReport {
+	report := notification.HealthReport{Name: PublisherPoolHealthName, Status: notification.HealthUp}
+	connections := make([]notification.HealthReport, len(p.publishers))
+	for i, publisher := range p.publishers {
+		connections[i] = publisher.CheckHealth(ctx)
+		report.Status = report.Status.Worse(connections[i].Status)
+		if report.Error == "" {
+			report.Error = connections[i].Error
+		}
+	}
+	report.Details = map[string]any{"connections": connections}
+	return report
+}
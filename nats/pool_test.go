@@ -0,0 +1,163 @@
+package nats
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPublisherPoolInvalid(t *testing.T) {
+	_, err := NewPublisherPool(0, RoundRobin)
+	assert.EqualValues(t, notification.InvalidArguments, err.(*notification.Error).Code)
+
+	_, err = NewPublisherPool(2, PoolStrategy(7))
+	assert.EqualValues(t, notification.InvalidArguments, err.(*notification.Error).Code)
+
+	_, err = NewPublisherPool(2, RoundRobin, WithURL("nats://127.0.0.1:1"), WithConnectRetries(1))
+	assert.Error(t, err)
+}
+
+func TestPublisherPool(t *testing.T) {
+	s := startServer(t)
+
+	nc, err := nats.Connect(s.ClientURL())
+	require.NoError(t, err)
+	defer nc.Close()
+	sub, err := nc.SubscribeSync("test-pool.*")
+	require.NoError(t, err)
+	require.NoError(t, nc.Flush())
+
+	roundRobin, err := NewPublisherPool(3, RoundRobin, WithURL(s.ClientURL()), WithSubjectPrefix("test-pool"))
+	require.NoError(t, err)
+	defer roundRobin.Close()
+	assert.Equal(t, 3, roundRobin.Size())
+	seen := map[*Publisher]bool{}
+	for i := 0; i < 3; i++ {
+		seen[roundRobin.pick("client-1")] = true
+	}
+	assert.Len(t, seen, 3)
+
+	hashed, err := NewPublisherPool(4, HashClientID, WithURL(s.ClientURL()), WithSubjectPrefix("test-pool"))
+	require.NoError(t, err)
+	defer hashed.Close()
+	assert.Same(t, hashed.pick("client-1"), hashed.pick("client-1"))
+
+	// A client's notifications arrive in order
+	const count = 50
+	for i := 0; i < count; i++ {
+		require.NoError(t, hashed.PublishNotification("client-1", "Title", strconv.Itoa(i), notification.TypeInfo, "system"))
+	}
+	for i := 0; i < count; i++ {
+		msg, err := sub.NextMsg(2 * time.Second)
+		require.NoError(t, err)
+		assert.Contains(t, string(msg.Data), `"message":"`+strconv.Itoa(i)+`"`)
+	}
+	require.NoError(t, hashed.PublishCustomNotification("client-2", &notification.Notification{ClientID: "client-2", Title: "Custom", Message: "Message", Source: "system"}))
+	msg, err := sub.NextMsg(2 * time.Second)
+	require.NoError(t, err)
+	assert.Contains(t, string(msg.Data), "Custom")
+
+	report := hashed.CheckHealth(context.Background())
+	assert.Equal(t, notification.HealthUp, report.Status)
+	assert.Len(t, report.Details["connections"], 4)
+
+	require.NoError(t, hashed.Shutdown(context.Background()))
+	err = hashed.PublishNotification("client-1", "Title", "Message", notification.TypeInfo, "system")
+	assert.ErrorIs(t, err, ErrPublisherClosed)
+}
+
+func TestPublisherPoolShutdownJoinsErrors(t *testing.T) {
+	s := startServer(t)
+
+	pool, err := NewPublisherPool(2, RoundRobin, WithURL(s.ClientURL()))
+	require.NoError(t, err)
+	defer pool.Close()
+
+	// Hold an in-flight publish on every connection so each shutdown times out
+	for _, publisher := range pool.publishers {
+		require.NoError(t, publisher.begin())
+		defer publisher.end()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = pool.Shutdown(ctx)
+	require.Error(t, err)
+	joined, ok := err.(interface{ Unwrap() []error })
+	require.True(t, ok)
+	assert.Len(t, joined.Unwrap(), 2)
+}
+
+func TestPublisherPoolSpool(t *testing.T) {
+	port := freePort(t)
+	url := "nats://127.0.0.1:" + strconv.Itoa(port)
+	dir := t.TempDir()
+
+	// Nothing is listening yet, so every publish is spooled
+	pool, err := NewPublisherPool(2, RoundRobin, WithURL(url), WithSubjectPrefix("test-pool-spool"), WithJetStream(false), WithLazyConnect(), WithSpool(SpoolConfig{Dir: dir}))
+	require.NoError(t, err)
+	defer pool.Close()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, pool.PublishNotification("client-1", "Spooled", strconv.Itoa(i), notification.TypeInfo, "system"))
+	}
+	for i, publisher := range pool.Publishers() {
+		assert.EqualValues(t, 2, publisher.SpoolStats().Spooled)
+		assert.DirExists(t, filepath.Join(dir, strconv.Itoa(i)))
+	}
+}
+
+func benchmarkPublisher(b *testing.B, publisher notification.PublisherPort) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			i++
+			clientID := "client-" + strconv.Itoa(i%1000)
+			if err := publisher.PublishNotification(clientID, "Bulk", "Benchmark message", notification.TypeInfo, "bulk"); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+	b.ReportMetric(float64(b.N)/b.Elapsed().Seconds(), "msgs/s")
+}
+
+func BenchmarkPublish(b *testing.B) {
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	require.NoError(b, err)
+	go s.Start()
+	require.True(b, s.ReadyForConnections(5*time.Second))
+	defer s.Shutdown()
+
+	opts := []Option{WithURL(s.ClientURL()), WithSubjectPrefix("bench-pool"), WithJetStream(false)}
+
+	b.Run("single", func(b *testing.B) {
+		publisher, err := New(opts...)
+		require.NoError(b, err)
+		defer publisher.Close()
+		benchmarkPublisher(b, publisher)
+	})
+	for _, size := range []int{4, 8} {
+		for _, strategy := range []PoolStrategy{RoundRobin, HashClientID} {
+			name := "pool-" + strconv.Itoa(size) + "-round-robin"
+			if strategy == HashClientID {
+				name = "pool-" + strconv.Itoa(size) + "-hash"
+			}
+			b.Run(name, func(b *testing.B) {
+				pool, err := NewPublisherPool(size, strategy, opts...)
+				require.NoError(b, err)
+				defer pool.Close()
+				benchmarkPublisher(b, pool)
+			})
+		}
+	}
+}
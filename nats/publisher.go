@@ -1,12 +1,17 @@
 package nats
 
 import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
 	"github.com/MyWeHub/notification-sdk/internal/natsutil"
 	"github.com/MyWeHub/notification-sdk/internal/utils"
 	"github.com/MyWeHub/notification-sdk/internal/validation"
 
 	notification "github.com/MyWeHub/notification-sdk"
-	"github.com/google/uuid"
 	"github.com/nats-io/nats.go"
 )
 
@@ -14,46 +19,86 @@ type Publisher struct {
 	nc            *nats.Conn
 	js            nats.JetStreamContext
 	subjectPrefix string
+	scheme        *natsutil.SubjectScheme
+	reportStatus  bool
+	legacy        bool
+	templates     notification.TemplateRendererPort
+	directory     notification.UserDirectoryPort
+	audiences     notification.AudienceResolverPort
+	topics        notification.TopicStorePort
+	preferences   notification.UserPreferencesPort
+	authorizer    notification.AuthorizerPort
+	principal     notification.Principal
+	codec         Codec
+	publish       PublishFunc
+	logger        *slog.Logger
+	metrics       Metrics
+	now           func() time.Time
+	newID         func() string
+	retry         RetryPolicy
+	disconnected  DisconnectedPolicy
+	events        *eventHub
+	ownsConn      bool
+	spool         *publisherSpool
+
+	lifecycleMu sync.Mutex
+	closed      bool
+	inflight    sync.WaitGroup
+
+	attachmentsOnce sync.Once
+	attachments     *AttachmentStore
 }
 
 // NewPublisher creates a new NATS notification publisher with default options
 func NewPublisher(natsURL, subjectPrefix string) (*Publisher, error) {
-	nc, err := natsutil.ConnectWithRetry(natsURL, 3)
-	if err != nil {
-		return nil, err
-	}
-
-	js, err := natsutil.CreateJetStreamContext(nc)
-	if err != nil {
-		nc.Close() // Clean up connection on error
-		return nil, err
-	}
-
-	return &Publisher{
-		nc:            nc,
-		js:            js,
-		subjectPrefix: subjectPrefix,
-	}, nil
+	return New(WithURL(natsURL), WithSubjectPrefix(subjectPrefix))
 }
 
-// NewPublisherWithOptions creates a new NATS notification publisher with custom options
+// NewPublisherWithOptions creates a new NATS notification publisher with custom options.
+// Only the given options are applied and the connection is not retried.
 func NewPublisherWithOptions(natsURL, subjectPrefix string, opts ...nats.Option) (*Publisher, error) {
-	nc, err := natsutil.ConnectWithCustomOptions(natsURL, opts...)
-	if err != nil {
-		return nil, err
-	}
+	return New(
+		WithURL(natsURL),
+		WithSubjectPrefix(subjectPrefix),
+		WithConnectRetries(1),
+		WithNATSOptions(opts...),
+		withoutDefaultConnectOptions(),
+	)
+}
 
-	js, err := natsutil.CreateJetStreamContext(nc)
-	if err != nil {
-		nc.Close()
-		return nil, err
-	}
+func newPublisher(nc *nats.Conn, js nats.JetStreamContext, o *publisherOptions) *Publisher {
+	// validate checked the prefix and the template
+	scheme, _ := natsutil.NewSubjectScheme(o.subjectTemplate, o.subjectPrefix)
 
-	return &Publisher{
+	p := &Publisher{
 		nc:            nc,
 		js:            js,
-		subjectPrefix: subjectPrefix,
-	}, nil
+		subjectPrefix: o.subjectPrefix,
+		scheme:        scheme,
+		reportStatus:  o.reportStatus,
+		legacy:        o.legacy,
+		templates:     o.templates,
+		directory:     o.directory,
+		audiences:     o.audiences,
+		topics:        o.topics,
+		preferences:   o.preferences,
+		authorizer:    o.authorizer,
+		principal:     o.principal,
+		codec:         o.codec,
+		logger:        o.logger,
+		metrics:       o.metrics,
+		now:           o.clock,
+		newID:         o.newID,
+		retry:         o.publishPolicy,
+		disconnected:  o.disconnected,
+		events:        o.events,
+	}
+
+	p.publish = p.send
+	for i := len(o.middleware) - 1; i >= 0; i-- {
+		p.publish = o.middleware[i](p.publish)
+	}
+	return p
 }
 
 func (p *Publisher) PublishNotification(clientID string, title string, message string, notificationType notification.NotificationType, source string) error {
@@ -72,13 +117,13 @@ func (p *Publisher) PublishNotification(clientID string, title string, message s
 	}
 
 	notif := &notification.Notification{
-		ID:        uuid.New().String(),
+		ID:        p.newID(),
 		ClientID:  clientID,
 		Title:     title,
 		Message:   message,
 		Type:      notificationType,
 		Read:      false,
-		CreatedAt: utils.UTCNow(),
+		CreatedAt: p.now(),
 		Source:    source,
 	}
 
@@ -92,54 +137,258 @@ func (p *Publisher) PublishCustomNotification(clientID string, notif *notificati
 	if err := validation.ValidateNotification(notif); err != nil {
 		return err
 	}
+	if notif.Audience != nil {
+		return notification.NewError(notification.InvalidArguments, "audience cannot be set on a single-client notification; use PublishToAudience")
+	}
 
 	// Auto-fill missing fields
 	if notif.ClientID == "" {
 		notif.ClientID = clientID
 	}
 	if notif.ID == "" {
-		notif.ID = uuid.New().String()
+		notif.ID = p.newID()
 	}
 	if utils.IsZeroTime(notif.CreatedAt) {
-		notif.CreatedAt = utils.UTCNow()
+		notif.CreatedAt = p.now()
 	}
 
 	return p.publishNotification(notif)
 }
 
+// WithTemplates sets the template renderer used by PublishTemplate
+func WithTemplates(renderer notification.TemplateRendererPort) Option {
+	return func(o *publisherOptions) {
+		o.templates = renderer
+	}
+}
+
+// WithUserDirectory sets the directory PublishTemplate resolves recipient locales from
+func WithUserDirectory(directory notification.UserDirectoryPort) Option {
+	return func(o *publisherOptions) {
+		o.directory = directory
+	}
+}
+
+// PublishTemplate renders a registered template with data and publishes the result. When a
+// user directory is configured the template is rendered in the recipient's locale, looked up
+// with the client ID as the user ID; use PublishTemplateToUser when the two differ.
+func (p *Publisher) PublishTemplate(clientID string, templateID string, data any) error {
+	return p.publishTemplate(clientID, "", templateID, data)
+}
+
+// PublishTemplateToUser renders a registered template in the locale of userID and publishes
+// it to clientID with the notification's UserID set
+func (p *Publisher) PublishTemplateToUser(clientID string, userID string, templateID string, data any) error {
+	if userID == "" {
+		err := notification.NewError(notification.InvalidArguments, "userID cannot be empty")
+		return err
+	}
+	return p.publishTemplate(clientID, userID, templateID, data)
+}
+
+func (p *Publisher) publishTemplate(clientID string, userID string, templateID string, data any) error {
+	if err := validation.ValidateClientID(clientID); err != nil {
+		return err
+	}
+	if err := validation.ValidateTemplateID(templateID); err != nil {
+		return err
+	}
+	if p.templates == nil {
+		err := notification.NewError(notification.InvalidArguments, "publisher has no template renderer configured")
+		return err
+	}
+
+	var content *notification.RenderedContent
+	var err error
+	if p.directory != nil {
+		recipient := userID
+		if recipient == "" {
+			recipient = clientID
+		}
+		locale, lookupErr := p.directory.GetUserLocale(context.Background(), recipient)
+		if lookupErr != nil {
+			return lookupErr
+		}
+		content, err = p.templates.RenderLocale(templateID, locale, data)
+	} else {
+		content, err = p.templates.Render(templateID, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	notif := &notification.Notification{
+		ID:              p.newID(),
+		ClientID:        clientID,
+		UserID:          userID,
+		Title:           content.Title,
+		Message:         content.Message,
+		Type:            content.Type,
+		Read:            false,
+		CreatedAt:       p.now(),
+		Source:          content.Source,
+		Locale:          content.Locale,
+		TemplateID:      content.TemplateID,
+		TemplateVersion: content.Version,
+		Variants:        content.Variants,
+	}
+	if err := validation.ValidateNotification(notif); err != nil {
+		return err
+	}
+
+	return p.publishNotification(notif)
+}
+
+// WithAuthorizer sets the policy every notification is checked against before it is
+// published, on behalf of principal. The principal is the identity of the service owning
+// the publisher and is passed to the authorizer in the context, so notifications cannot
+// claim another source.
+func WithAuthorizer(authorizer notification.AuthorizerPort, principal notification.Principal) Option {
+	return func(o *publisherOptions) {
+		o.authorizer = authorizer
+		o.principal = principal
+	}
+}
+
+func (p *Publisher) authorize(ctx context.Context, notif *notification.Notification) error {
+	if p.authorizer == nil {
+		return nil
+	}
+	return p.authorizer.Authorize(notification.ContextWithPrincipal(ctx, p.principal), notif)
+}
+
 // publishNotification is a private helper method that handles the actual publishing
 func (p *Publisher) publishNotification(notif *notification.Notification) error {
-	// Use internal JSON utility
-	data, err := utils.MarshalNotification(notif)
-	if err != nil {
+	if err := p.authorize(context.Background(), notif); err != nil {
+		return err
+	}
+	return p.publishAuthorized(notif)
+}
+
+// publishAuthorized publishes a notification that already passed authorization
+func (p *Publisher) publishAuthorized(notif *notification.Notification) error {
+	// Use the configured subject scheme
+	return p.publishToSubject(p.scheme.Build(notif), notif)
+}
+
+// publishToSubject runs a notification through the middleware chain onto an explicit subject
+func (p *Publisher) publishToSubject(subject string, notif *notification.Notification) error {
+	if err := p.begin(); err != nil {
+		return err
+	}
+	defer p.end()
+
+	if err := p.publish(context.Background(), subject, notif); err != nil {
 		return err
 	}
 
-	// Use internal subject builder
-	subject := natsutil.BuildSubject(p.subjectPrefix, notif.ClientID)
+	if p.reportStatus {
+		// The notification is already out; failing here would make callers publish it again
+		err := p.reportStatusEvent(&notification.StatusEvent{
+			NotificationID: notif.ID,
+			ClientID:       notif.ClientID,
+			Status:         notification.StatusPublished,
+			Reporter:       notif.Source,
+		})
+		if err != nil {
+			p.logger.Error("failed to report published status", "id", notif.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// send encodes and publishes a notification; it is the innermost PublishFunc
+func (p *Publisher) send(ctx context.Context, subject string, notif *notification.Notification) error {
+	data, err := p.codec.Encode(notif)
+	if err != nil {
+		return err
+	}
 
 	// Validate subject before publishing
-	if !natsutil.ValidateSubject(subject) {
-		err := notification.NewError(notification.InvalidArguments, "invalid subject: "+subject)
+	if err := natsutil.ValidatePublishSubject(subject); err != nil {
 		return err
 	}
 
-	err = p.nc.Publish(subject, data)
+	start := time.Now()
+	// Legacy copies are not spooled
+	if p.spool != nil {
+		if spooled, err := p.spoolIfDisconnected(subject, data); spooled || err != nil {
+			p.metrics.ObservePublish(subject, time.Since(start), err)
+			return err
+		}
+	}
+
+	spooled := false
+	err = p.retry.Retry(ctx, func(context.Context) error {
+		if p.disconnected == RejectWhileDisconnected && !p.nc.IsConnected() && !p.nc.IsClosed() {
+			return errNotConnected
+		}
+		err := p.nc.Publish(subject, data)
+		if p.spoolable(err) {
+			spooled = true
+			return nil
+		}
+		return retryable(err)
+	})
+	if spooled {
+		err := p.spoolRecord(subject, data)
+		p.metrics.ObservePublish(subject, time.Since(start), err)
+		return err
+	}
+	p.metrics.ObservePublish(subject, time.Since(start), err)
 	if err != nil {
+		p.logger.Error("failed to publish notification", "subject", subject, "id", notif.ID, "error", err)
 		err := notification.NewError(notification.Internal, "failed to publish notification: "+err.Error())
 		return err
 	}
 
+	if p.legacy {
+		if err := p.publishLegacy(subject, data); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// Close closes the connection at once, dropping buffered messages; use Shutdown to drain it.
+// A connection passed to NewFromConn is left open.
 func (p *Publisher) Close() error {
-	if p.nc != nil {
+	p.markClosed()
+	if p.nc != nil && p.ownsConn {
 		p.nc.Close()
 	}
+	p.closeSpool()
 	return nil
 }
 
+// retryable marks publish errors that retrying cannot fix as permanent
+func retryable(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, nats.ErrConnectionClosed), errors.Is(err, nats.ErrMaxPayload),
+		errors.Is(err, nats.ErrBadSubject), errors.Is(err, nats.ErrNoStreamResponse):
+		return natsutil.Permanent(err)
+	default:
+		return err
+	}
+}
+
+// jetStream returns the JetStream context, failing when JetStream was disabled
+func (p *Publisher) jetStream() (nats.JetStreamContext, error) {
+	return requireJetStream(p.js)
+}
+
+// requireJetStream fails for features that need JetStream when there is no context
+func requireJetStream(js nats.JetStreamContext) (nats.JetStreamContext, error) {
+	if js == nil {
+		return nil, notification.NewError(notification.InvalidArguments, "JetStream is disabled; this feature requires a JetStream context")
+	}
+	return js, nil
+}
+
 // IsConnected returns true if the NATS connection is active
 func (p *Publisher) IsConnected() bool {
 	return p.nc != nil && p.nc.IsConnected()
@@ -1,11 +1,15 @@
 package nats
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 	"time"
 
 	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/MyWeHub/notification-sdk/memory"
+	"github.com/MyWeHub/notification-sdk/policy"
+	"github.com/MyWeHub/notification-sdk/templates"
 	"github.com/nats-io/nats.go"
 	"github.com/stretchr/testify/assert"
 )
@@ -178,3 +182,401 @@ func TestPublishCustomNotificationValidation(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "notification cannot be nil")
 }
+
+type fakeUserDirectory map[string]string
+
+func (d fakeUserDirectory) GetUserLocale(_ context.Context, userID string) (string, error) {
+	return d[userID], nil
+}
+
+func TestPublishTemplate(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	publisher, err := NewPublisher(nats.DefaultURL, "test-notifications")
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	err = publisher.PublishTemplate("test-client", "welcome", nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no template renderer configured")
+
+	registry := templates.NewRegistry()
+	err = registry.Register(templates.Template{
+		ID:      "welcome",
+		Version: 1,
+		Type:    notification.TypeInfo,
+		Source:  "auth-service",
+		Title:   "Welcome {{.Name}}!",
+		Message: "Hello {{.Name}}, your account is ready",
+		Variants: map[notification.Channel]templates.Variant{
+			notification.ChannelSMS: {Body: "Welcome {{.Name}}"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to register template: %v", err)
+	}
+	rendering, err := New(WithURL(nats.DefaultURL), WithSubjectPrefix("test-notifications"), WithTemplates(registry))
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer rendering.Close()
+
+	subject := "test-notifications.test-client"
+	ch := make(chan *notification.Notification, 1)
+	subscription, err := nc.Subscribe(subject, func(msg *nats.Msg) {
+		var n notification.Notification
+		if err := json.Unmarshal(msg.Data, &n); err != nil {
+			t.Errorf("Failed to unmarshal notification: %v", err)
+			return
+		}
+		ch <- &n
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe to NATS: %v", err)
+	}
+	defer subscription.Unsubscribe()
+
+	if err := nc.Flush(); err != nil {
+		t.Fatalf("Failed to flush connection: %v", err)
+	}
+
+	err = rendering.PublishTemplate("test-client", "welcome", map[string]string{"Name": "Ann"})
+	if err != nil {
+		t.Fatalf("Failed to publish template: %v", err)
+	}
+
+	select {
+	case n := <-ch:
+		assert.Equal(t, "Welcome Ann!", n.Title)
+		assert.Equal(t, "Hello Ann, your account is ready", n.Message)
+		assert.Equal(t, "auth-service", n.Source)
+		assert.Equal(t, "welcome", n.TemplateID)
+		assert.Equal(t, 1, n.TemplateVersion)
+		assert.Equal(t, "en", n.Locale)
+		assert.Equal(t, "Welcome Ann", n.Variants[notification.ChannelSMS].Body)
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timed out waiting for notification")
+	}
+
+	err = registry.Register(templates.Template{
+		ID:      "welcome",
+		Version: 1,
+		Locale:  "fr",
+		Type:    notification.TypeInfo,
+		Source:  "auth-service",
+		Title:   "Bienvenue {{.Name}} !",
+		Message: "Bonjour {{.Name}}, votre compte est prêt",
+	})
+	if err != nil {
+		t.Fatalf("Failed to register template: %v", err)
+	}
+	localized, err := New(
+		WithURL(nats.DefaultURL),
+		WithSubjectPrefix("test-notifications"),
+		WithTemplates(registry),
+		WithUserDirectory(fakeUserDirectory{"test-client": "fr-CA", "user-1": "en-GB"}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer localized.Close()
+
+	err = localized.PublishTemplate("test-client", "welcome", map[string]string{"Name": "Ann"})
+	if err != nil {
+		t.Fatalf("Failed to publish template: %v", err)
+	}
+
+	select {
+	case n := <-ch:
+		assert.Equal(t, "Bienvenue Ann !", n.Title)
+		assert.Equal(t, "fr", n.Locale)
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timed out waiting for notification")
+	}
+
+	err = localized.PublishTemplateToUser("test-client", "user-1", "welcome", map[string]string{"Name": "Ann"})
+	if err != nil {
+		t.Fatalf("Failed to publish template: %v", err)
+	}
+
+	select {
+	case n := <-ch:
+		assert.Equal(t, "Welcome Ann!", n.Title)
+		assert.Equal(t, "user-1", n.UserID)
+	case <-time.After(3 * time.Second):
+		t.Fatal("Timed out waiting for notification")
+	}
+
+	err = rendering.PublishTemplate("test-client", "unknown", nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
+
+func TestPublishHierarchicalSubject(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	_, err = New(WithURL(nats.DefaultURL), WithSubjectPrefix("test-hierarchy"), WithSubjectTemplate("{prefix}.{user}"))
+	assert.Error(t, err)
+	publisher, err := New(WithURL(nats.DefaultURL), WithSubjectPrefix("test-hierarchy"), WithSubjectTemplate(HierarchicalSubjectTemplate))
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	scheme := publisher.SubjectScheme()
+	ch := make(chan *nats.Msg, 2)
+	subscription, err := nc.ChanSubscribe(scheme.Wildcard(SubjectParts{Type: notification.TypeError.String()}), ch)
+	if err != nil {
+		t.Fatalf("Failed to subscribe to NATS: %v", err)
+	}
+	defer subscription.Unsubscribe()
+	if err := nc.Flush(); err != nil {
+		t.Fatalf("Failed to flush connection: %v", err)
+	}
+
+	assert.NoError(t, publisher.PublishNotification("client-1", "Saved", "All good", notification.TypeSuccess, "billing"))
+	assert.NoError(t, publisher.PublishNotification("client-1", "Failed", "Payment declined", notification.TypeError, "billing"))
+
+	select {
+	case msg := <-ch:
+		assert.Equal(t, "test-hierarchy.client-1._.error.billing", msg.Subject)
+		parts, err := scheme.Parse(msg.Subject)
+		assert.NoError(t, err)
+		assert.Equal(t, SubjectParts{ClientID: "client-1", Type: "error", Source: "billing"}, parts)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for notification")
+	}
+
+	select {
+	case msg := <-ch:
+		t.Fatalf("Unexpected notification on %s", msg.Subject)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPublishLegacySubjects(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	publisher, err := NewPublisher(nats.DefaultURL, "test-legacy")
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	ch := make(chan *nats.Msg, 4)
+	subscription, err := nc.ChanSubscribe("test-legacy.*", ch)
+	if err != nil {
+		t.Fatalf("Failed to subscribe to NATS: %v", err)
+	}
+	defer subscription.Unsubscribe()
+	if err := nc.Flush(); err != nil {
+		t.Fatalf("Failed to flush connection: %v", err)
+	}
+
+	receive := func() *nats.Msg {
+		select {
+		case msg := <-ch:
+			return msg
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timeout waiting for notification")
+			return nil
+		}
+	}
+
+	assert.NoError(t, publisher.PublishNotification("a.b", "Title", "Message", notification.TypeInfo, "system"))
+	msg := receive()
+	assert.Equal(t, "test-legacy.a%2Eb", msg.Subject)
+	clientID, err := DecodeSubjectToken("a%2Eb")
+	assert.NoError(t, err)
+	assert.Equal(t, "a.b", clientID)
+
+	legacy, err := New(WithURL(nats.DefaultURL), WithSubjectPrefix("test-legacy"), WithLegacySubjects())
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer legacy.Close()
+	assert.NoError(t, legacy.PublishNotification("a.b", "Title", "Message", notification.TypeInfo, "system"))
+	assert.NoError(t, legacy.PublishNotification("client-1", "Title", "Message", notification.TypeInfo, "system"))
+
+	msg = receive()
+	assert.Equal(t, "test-legacy.a%2Eb", msg.Subject)
+	assert.Empty(t, msg.Header.Get(LegacySubjectHeader))
+	msg = receive()
+	assert.Equal(t, "test-legacy.a_b", msg.Subject)
+	assert.Equal(t, "test-legacy.a%2Eb", msg.Header.Get(LegacySubjectHeader))
+
+	// Subjects that the legacy encoding already produced are published once
+	msg = receive()
+	assert.Equal(t, "test-legacy.client-1", msg.Subject)
+	select {
+	case msg := <-ch:
+		t.Fatalf("Unexpected notification on %s", msg.Subject)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPublishInvalidSubject(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	// The prefix is rejected up front instead of failing every publish
+	_, err = NewPublisher(nats.DefaultURL, "test.notifications.")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ends with '.'")
+}
+
+func TestPublishWithAuthorizer(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	engine, err := policy.New(&policy.Policy{Rules: []policy.Rule{
+		{Sources: []string{"billing-service"}, Types: []string{"info", "warning"}},
+	}}, nil)
+	if err != nil {
+		t.Fatalf("Failed to create policy engine: %v", err)
+	}
+	_, err = New(WithURL(nats.DefaultURL), WithSubjectPrefix("test-authorization"), WithAuthorizer(engine, notification.Principal{}))
+	assert.Error(t, err)
+	publisher, err := New(
+		WithURL(nats.DefaultURL),
+		WithSubjectPrefix("test-authorization"),
+		WithAuthorizer(engine, notification.Principal{Source: "billing-service"}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	ch := make(chan *nats.Msg, 2)
+	subscription, err := nc.ChanSubscribe("test-authorization.*", ch)
+	if err != nil {
+		t.Fatalf("Failed to subscribe to NATS: %v", err)
+	}
+	defer subscription.Unsubscribe()
+	if err := nc.Flush(); err != nil {
+		t.Fatalf("Failed to flush connection: %v", err)
+	}
+
+	err = publisher.PublishNotification("client-1", "Failed", "Payment declined", notification.TypeError, "billing-service")
+	if assert.Error(t, err) {
+		assert.EqualValues(t, notification.PermissionDenied, err.(*notification.Error).Code)
+	}
+	err = publisher.PublishCustomNotification("client-1", &notification.Notification{
+		ClientID: "client-1",
+		Title:    "Hello",
+		Message:  "Welcome",
+		Source:   "marketing",
+	})
+	assert.Error(t, err)
+	// A notification cannot claim a source the publisher is not bound to
+	err = publisher.PublishNotification("client-1", "Invoice", "Invoice ready", notification.TypeInfo, "ops-alerts")
+	if assert.Error(t, err) {
+		assert.EqualValues(t, notification.PermissionDenied, err.(*notification.Error).Code)
+	}
+
+	assert.NoError(t, publisher.PublishNotification("client-1", "Invoice", "Invoice ready", notification.TypeInfo, "billing-service"))
+	select {
+	case msg := <-ch:
+		assert.Equal(t, "test-authorization.client-1", msg.Subject)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for notification")
+	}
+	select {
+	case msg := <-ch:
+		t.Fatalf("Unexpected notification on %s", msg.Subject)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestPublishWithForgedAudience(t *testing.T) {
+	s := startServer(t)
+	nc, err := nats.Connect(s.ClientURL())
+	if err != nil {
+		t.Fatalf("Failed to connect to NATS: %v", err)
+	}
+	defer nc.Close()
+
+	registry := memory.NewTenantRegistry()
+	assert.NoError(t, registry.AddTenant(notification.Tenant{ID: "acme"}))
+	assert.NoError(t, registry.AddTenant(notification.Tenant{ID: "evil"}))
+	assert.NoError(t, registry.AddClient("acme", "acme-client"))
+	assert.NoError(t, registry.AddClient("evil", "evil-client"))
+	engine, err := policy.New(&policy.Policy{Rules: []policy.Rule{{Sources: []string{"evil-service"}, OwnTenant: true}}}, registry)
+	if err != nil {
+		t.Fatalf("Failed to create policy engine: %v", err)
+	}
+
+	topics := memory.NewTopicStore()
+	assert.NoError(t, topics.Follow(context.Background(), notification.TopicSubscription{Topic: "project:deals", UserID: "u-1", ClientID: "acme-client"}))
+	publisher, err := New(
+		WithURL(s.ClientURL()),
+		WithSubjectPrefix("test-forged"),
+		WithAuthorizer(engine, notification.Principal{Source: "evil-service", TenantID: "evil"}),
+		WithTopicStore(topics),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	sub, err := nc.SubscribeSync("test-forged.>")
+	if err != nil {
+		t.Fatalf("Failed to subscribe to NATS: %v", err)
+	}
+	if err := nc.Flush(); err != nil {
+		t.Fatalf("Failed to flush connection: %v", err)
+	}
+
+	forged := func() *notification.Notification {
+		return &notification.Notification{
+			ClientID: "acme-client",
+			Title:    "Deal",
+			Message:  "Click here",
+			Source:   "evil-service",
+			Audience: &notification.Audience{Kind: notification.AudienceOrganization, OrgID: "evil"},
+		}
+	}
+
+	// An organization audience set by the caller does not make a notification a broadcast
+	err = publisher.PublishCustomNotification("acme-client", forged())
+	if assert.Error(t, err) {
+		assert.EqualValues(t, notification.InvalidArguments, err.(*notification.Error).Code)
+	}
+	err = publisher.PublishToTopic(context.Background(), "project:deals", forged())
+	if assert.Error(t, err) {
+		assert.EqualValues(t, notification.PermissionDenied, err.(*notification.Error).Code)
+	}
+	err = publisher.PublishToAudience(context.Background(), notification.Audience{Kind: notification.AudienceOrganization, OrgID: "acme"}, forged())
+	if assert.Error(t, err) {
+		assert.EqualValues(t, notification.PermissionDenied, err.(*notification.Error).Code)
+	}
+
+	// The real broadcast path still reaches the principal's own organization
+	assert.NoError(t, publisher.PublishToAudience(context.Background(), notification.Audience{Kind: notification.AudienceOrganization, OrgID: "evil"}, forged()))
+	msg, err := sub.NextMsg(2 * time.Second)
+	if assert.NoError(t, err) {
+		assert.Equal(t, OrgBroadcastSubject("test-forged", "evil"), msg.Subject)
+	}
+	_, err = sub.NextMsg(100 * time.Millisecond)
+	assert.ErrorIs(t, err, nats.ErrTimeout)
+}
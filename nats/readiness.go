@@ -0,0 +1,66 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+// DisconnectedPolicy decides what happens to publishes while a publisher is not connected
+type DisconnectedPolicy int
+
+const (
+	// BufferWhileDisconnected keeps publishes in the client's reconnect buffer and sends
+	// them once connected; publishes fail when the buffer is full
+	BufferWhileDisconnected DisconnectedPolicy = iota
+	// RejectWhileDisconnected fails publishes at once while disconnected. With a publish
+	// retry policy they are retried and succeed if the connection returns in time.
+	RejectWhileDisconnected
+)
+
+var errNotConnected = errors.New("publisher is not connected to NATS")
+
+// readinessPollInterval is how often WaitReady checks the connection
+const readinessPollInterval = 25 * time.Millisecond
+
+// Ready reports whether the publisher is connected and can publish without buffering
+func (p *Publisher) Ready() bool {
+	return p.IsConnected() && !p.isClosed()
+}
+
+// WaitReady blocks until the publisher is connected, ctx is done or the publisher is closed
+func (p *Publisher) WaitReady(ctx context.Context) error {
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if p.Ready() {
+			return nil
+		}
+		if p.nc == nil || p.nc.IsClosed() || p.isClosed() {
+			return ErrPublisherClosed
+		}
+
+		select {
+		case <-ctx.Done():
+			return notification.NewError(notification.Internal, "publisher not ready: "+ctx.Err().Error())
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReadinessHandler answers readiness probes: 200 while the publisher is connected and 503 otherwise
+func (p *Publisher) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if !p.Ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("not ready: " + p.GetConnectionStatus().String() + "\n"))
+			return
+		}
+		_, _ = w.Write([]byte("ready\n"))
+	})
+}
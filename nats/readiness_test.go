@@ -0,0 +1,89 @@
+package nats
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestLazyConnect(t *testing.T) {
+	port := freePort(t)
+	url := "nats://127.0.0.1:" + strconv.Itoa(port)
+	fastReconnect := WithNATSOptions(nats.ReconnectWait(20*time.Millisecond), nats.ReconnectJitter(0, 0))
+
+	start := time.Now()
+	// The buffering publisher reconnects slowly enough for the test to subscribe first
+	buffering, err := New(WithURL(url), WithSubjectPrefix("test-lazy"), WithLazyConnect(),
+		WithNATSOptions(nats.ReconnectWait(500*time.Millisecond), nats.ReconnectJitter(0, 0)))
+	require.NoError(t, err)
+	defer buffering.Close()
+	rejecting, err := New(WithURL(url), WithSubjectPrefix("test-lazy"), WithLazyConnect(), WithDisconnectedPolicy(RejectWhileDisconnected), fastReconnect)
+	require.NoError(t, err)
+	defer rejecting.Close()
+	assert.Less(t, time.Since(start), time.Second)
+
+	assert.False(t, buffering.Ready())
+	recorder := httptest.NewRecorder()
+	buffering.ReadinessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	assert.Error(t, buffering.WaitReady(ctx))
+	cancel()
+
+	assert.NoError(t, buffering.PublishNotification("client-1", "Buffered", "Sent once connected", notification.TypeInfo, "system"))
+	assert.ErrorContains(t, rejecting.PublishNotification("client-1", "Rejected", "Not sent", notification.TypeInfo, "system"), "not connected")
+
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: port, NoLog: true, NoSigs: true})
+	require.NoError(t, err)
+	go s.Start()
+	require.True(t, s.ReadyForConnections(5*time.Second))
+	defer s.Shutdown()
+
+	// Subscribe before the publisher reconnects and flushes its buffer
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+	ch := make(chan *nats.Msg, 2)
+	_, err = nc.ChanSubscribe("test-lazy.*", ch)
+	require.NoError(t, err)
+	require.NoError(t, nc.Flush())
+
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, buffering.WaitReady(ctx))
+	require.NoError(t, rejecting.WaitReady(ctx))
+
+	recorder = httptest.NewRecorder()
+	buffering.ReadinessHandler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+
+	select {
+	case msg := <-ch:
+		assert.Contains(t, string(msg.Data), "Buffered")
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for buffered notification")
+	}
+	assert.NoError(t, rejecting.PublishNotification("client-1", "Connected", "Sent", notification.TypeInfo, "system"))
+
+	rejecting.Close()
+	assert.Error(t, rejecting.WaitReady(context.Background()))
+}
@@ -0,0 +1,22 @@
+package nats
+
+import (
+	"github.com/MyWeHub/notification-sdk/internal/natsutil"
+)
+
+// RetryPolicy controls retries of connecting and publishing: attempts, exponential
+// backoff with jitter and an optional bound on the total time spent
+type RetryPolicy = natsutil.RetryPolicy
+
+// RetryError reports the error of every failed attempt; errors.Is and errors.As see each of them
+type RetryError = natsutil.RetryError
+
+// DefaultRetryPolicy returns the connect policy: 3 attempts backing off from 1s to 30s with 20% jitter
+func DefaultRetryPolicy() RetryPolicy {
+	return natsutil.DefaultRetryPolicy()
+}
+
+// NoRetry returns a policy making a single attempt; it is the default for publishing
+func NoRetry() RetryPolicy {
+	return natsutil.NoRetry()
+}
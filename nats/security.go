@@ -0,0 +1,18 @@
+package nats
+
+import (
+	"github.com/MyWeHub/notification-sdk/internal/natsutil"
+)
+
+// SecurityConfig holds the credentials used to connect to NATS: a creds file, an NKey
+// seed file, user/password or a token, optionally combined with TLS
+type SecurityConfig = natsutil.SecurityConfig
+
+// TLSConfig configures TLS, and with a client certificate mutual TLS, for NATS connections
+type TLSConfig = natsutil.TLSConfig
+
+// NewSecurePublisher creates a publisher authenticating with a security configuration.
+// The configuration is validated before connecting.
+func NewSecurePublisher(natsURL, subjectPrefix string, security SecurityConfig) (*Publisher, error) {
+	return New(WithURL(natsURL), WithSubjectPrefix(subjectPrefix), WithSecurity(security))
+}
@@ -0,0 +1,69 @@
+package nats
+
+import (
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/MyWeHub/notification-sdk/memory"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSecurePublisher(t *testing.T) {
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true, JetStream: true, StoreDir: t.TempDir(), Authorization: "s3cret"})
+	require.NoError(t, err)
+	go s.Start()
+	require.True(t, s.ReadyForConnections(5*time.Second))
+	defer s.Shutdown()
+
+	_, err = NewSecurePublisher(s.ClientURL(), "test-secure", SecurityConfig{Token: "s3cret", Username: "notifier"})
+	assert.Error(t, err)
+
+	_, err = NewSecurePublisher(s.ClientURL(), "test-secure", SecurityConfig{Token: "wrong"})
+	if assert.Error(t, err) {
+		assert.EqualValues(t, notification.Unauthorized, err.(*notification.Error).Code)
+	}
+
+	publisher, err := NewSecurePublisher(s.ClientURL(), "test-secure", SecurityConfig{Token: "s3cret"})
+	require.NoError(t, err)
+	defer publisher.Close()
+	assert.True(t, publisher.IsConnected())
+	assert.NoError(t, publisher.PublishNotification("client-1", "Title", "Message", notification.TypeInfo, "system"))
+}
+
+func TestSecureSubscribers(t *testing.T) {
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true, JetStream: true, StoreDir: t.TempDir(), Authorization: "s3cret"})
+	require.NoError(t, err)
+	go s.Start()
+	require.True(t, s.ReadyForConnections(5*time.Second))
+	defer s.Shutdown()
+
+	security := &SecurityConfig{Token: "s3cret"}
+	wrong := &SecurityConfig{Token: "wrong"}
+
+	_, err = NewDispatcher(s.ClientURL(), "test-secure", DispatcherConfig{Security: wrong})
+	if assert.Error(t, err) {
+		assert.EqualValues(t, notification.Unauthorized, err.(*notification.Error).Code)
+	}
+	dispatcher, err := NewDispatcher(s.ClientURL(), "test-secure", DispatcherConfig{Security: security})
+	require.NoError(t, err)
+	defer dispatcher.Close()
+
+	_, err = NewActionRouter(s.ClientURL(), "test-secure", ActionRouterConfig{Durable: "secure", Security: wrong})
+	assert.Error(t, err)
+	router, err := NewActionRouter(s.ClientURL(), "test-secure", ActionRouterConfig{Durable: "secure", Security: security})
+	require.NoError(t, err)
+	defer router.Close()
+
+	_, err = NewSecureStatusTracker(s.ClientURL(), "test-secure", memory.NewStatusStore(), *wrong)
+	assert.Error(t, err)
+	tracker, err := NewSecureStatusTracker(s.ClientURL(), "test-secure", memory.NewStatusStore(), *security)
+	require.NoError(t, err)
+	defer tracker.Close()
+
+	// Without credentials the locked-down server refuses every subscriber
+	_, err = NewStatusTracker(s.ClientURL(), "test-secure", memory.NewStatusStore())
+	assert.Error(t, err)
+}
@@ -0,0 +1,131 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats.go"
+)
+
+// ErrPublisherClosed is returned by publishes made after Shutdown or Close
+var ErrPublisherClosed = notification.NewError(notification.Internal, "publisher closed")
+
+// begin registers an in-flight publish, failing once the publisher is shutting down.
+// Every successful begin must be paired with end.
+func (p *Publisher) begin() error {
+	p.lifecycleMu.Lock()
+	defer p.lifecycleMu.Unlock()
+	if p.closed {
+		return ErrPublisherClosed
+	}
+	p.inflight.Add(1)
+	return nil
+}
+
+func (p *Publisher) end() {
+	p.inflight.Done()
+}
+
+// markClosed stops new publishes and reports whether the publisher was still open
+func (p *Publisher) markClosed() bool {
+	p.lifecycleMu.Lock()
+	defer p.lifecycleMu.Unlock()
+	if p.closed {
+		return false
+	}
+	p.closed = true
+	return true
+}
+
+func (p *Publisher) isClosed() bool {
+	p.lifecycleMu.Lock()
+	defer p.lifecycleMu.Unlock()
+	return p.closed
+}
+
+// Shutdown stops accepting publishes, waits for in-flight publishes, including their
+// synchronous JetStream acks, then drains the connection so buffered messages reach the server.
+// When ctx is done first the connection is closed at once and the error says what was
+// still pending. Shutting down a closed publisher does nothing. A connection passed to
+// NewFromConn is flushed instead of drained and left open.
+func (p *Publisher) Shutdown(ctx context.Context) error {
+	if !p.markClosed() || p.nc == nil {
+		return nil
+	}
+	defer p.closeSpool()
+
+	idle := make(chan struct{})
+	go func() {
+		p.inflight.Wait()
+		close(idle)
+	}()
+	select {
+	case <-idle:
+	case <-ctx.Done():
+		p.abort()
+		return notification.NewError(notification.Internal, "publisher shutdown: waiting for in-flight publishes: "+ctx.Err().Error())
+	}
+
+	if !p.ownsConn {
+		return p.flush(ctx)
+	}
+
+	if !p.nc.IsConnected() {
+		// Drain cannot flush while disconnected; only buffered publishes are lost
+		buffered, _ := p.nc.Buffered()
+		p.nc.Close()
+		if buffered > 0 {
+			return notification.NewError(notification.Internal, "publisher shutdown: dropped "+strconv.Itoa(buffered)+" buffered bytes while disconnected")
+		}
+		return nil
+	}
+
+	// Drain reports its failures asynchronously, as the connection's last error
+	lastErr := p.nc.LastError()
+	if err := p.nc.Drain(); err != nil {
+		if errors.Is(err, nats.ErrConnectionClosed) {
+			return nil
+		}
+		return notification.NewError(notification.Internal, "failed to drain connection: "+err.Error())
+	}
+
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+	for !p.nc.IsClosed() {
+		select {
+		case <-ctx.Done():
+			p.nc.Close()
+			return notification.NewError(notification.Internal, "publisher shutdown: draining connection: "+ctx.Err().Error())
+		case <-ticker.C:
+		}
+	}
+
+	if err := p.nc.LastError(); err != nil && err != lastErr {
+		return notification.NewError(notification.Internal, "failed to drain connection: "+err.Error())
+	}
+	return nil
+}
+
+// abort closes the connection when the publisher owns it
+func (p *Publisher) abort() {
+	if p.ownsConn {
+		p.nc.Close()
+	}
+}
+
+// flush waits until the server has received everything published on a shared connection
+func (p *Publisher) flush(ctx context.Context) error {
+	var err error
+	if _, ok := ctx.Deadline(); ok {
+		err = p.nc.FlushWithContext(ctx)
+	} else {
+		err = p.nc.Flush()
+	}
+	if err != nil && !errors.Is(err, nats.ErrConnectionClosed) {
+		return notification.NewError(notification.Internal, "failed to flush connection: "+err.Error())
+	}
+	return nil
+}
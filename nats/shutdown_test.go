@@ -0,0 +1,126 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startServer(t *testing.T) *server.Server {
+	t.Helper()
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	require.NoError(t, err)
+	go s.Start()
+	require.True(t, s.ReadyForConnections(5*time.Second))
+	t.Cleanup(s.Shutdown)
+	return s
+}
+
+func TestShutdown(t *testing.T) {
+	s := startServer(t)
+
+	nc, err := nats.Connect(s.ClientURL())
+	require.NoError(t, err)
+	defer nc.Close()
+	sub, err := nc.SubscribeSync("test-shutdown.*")
+	require.NoError(t, err)
+	require.NoError(t, nc.Flush())
+
+	// A middleware holds the publish in flight until released
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	hold := func(next PublishFunc) PublishFunc {
+		return func(ctx context.Context, subject string, notif *notification.Notification) error {
+			close(entered)
+			<-release
+			return next(ctx, subject, notif)
+		}
+	}
+	publisher, err := New(WithURL(s.ClientURL()), WithSubjectPrefix("test-shutdown"), WithMiddleware(hold))
+	require.NoError(t, err)
+
+	published := make(chan error, 1)
+	go func() {
+		published <- publisher.PublishNotification("client-1", "In flight", "Published during shutdown", notification.TypeInfo, "system")
+	}()
+	<-entered
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- publisher.Shutdown(context.Background())
+	}()
+
+	// New publishes fail as soon as shutdown starts
+	require.Eventually(t, publisher.isClosed, time.Second, 10*time.Millisecond)
+	err = publisher.PublishNotification("client-1", "Late", "Rejected", notification.TypeInfo, "system")
+	assert.True(t, errors.Is(err, ErrPublisherClosed))
+	assert.EqualValues(t, notification.Internal, err.(*notification.Error).Code)
+	assert.ErrorIs(t, publisher.ReportStatus(&notification.StatusEvent{NotificationID: "n-1", Status: notification.StatusRead}), ErrPublisherClosed)
+
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned before the in-flight publish finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	assert.NoError(t, <-published)
+	assert.NoError(t, <-shutdown)
+	assert.True(t, publisher.nc.IsClosed())
+	assert.False(t, publisher.Ready())
+
+	msg, err := sub.NextMsg(2 * time.Second)
+	require.NoError(t, err)
+	assert.Contains(t, string(msg.Data), "In flight")
+
+	assert.NoError(t, publisher.Shutdown(context.Background()))
+	assert.ErrorIs(t, publisher.WaitReady(context.Background()), ErrPublisherClosed)
+}
+
+func TestShutdownTimeout(t *testing.T) {
+	s := startServer(t)
+
+	release := make(chan struct{})
+	defer close(release)
+	entered := make(chan struct{})
+	hold := func(next PublishFunc) PublishFunc {
+		return func(ctx context.Context, subject string, notif *notification.Notification) error {
+			close(entered)
+			<-release
+			return next(ctx, subject, notif)
+		}
+	}
+	publisher, err := New(WithURL(s.ClientURL()), WithSubjectPrefix("test-shutdown"), WithMiddleware(hold))
+	require.NoError(t, err)
+
+	go func() {
+		_ = publisher.PublishNotification("client-1", "Stuck", "Never finishes", notification.TypeInfo, "system")
+	}()
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = publisher.Shutdown(ctx)
+	assert.ErrorContains(t, err, "in-flight publishes")
+	assert.ErrorContains(t, err, context.DeadlineExceeded.Error())
+	assert.True(t, publisher.nc.IsClosed())
+}
+
+func TestCloseRejectsPublishes(t *testing.T) {
+	s := startServer(t)
+
+	publisher, err := New(WithURL(s.ClientURL()), WithSubjectPrefix("test-shutdown"))
+	require.NoError(t, err)
+	require.NoError(t, publisher.Close())
+
+	err = publisher.PublishNotification("client-1", "Closed", "Rejected", notification.TypeInfo, "system")
+	assert.ErrorIs(t, err, ErrPublisherClosed)
+	assert.NoError(t, publisher.Shutdown(context.Background()))
+}
@@ -0,0 +1,242 @@
+package nats
+
+import (
+	"encoding/binary"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/MyWeHub/notification-sdk/internal/spool"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats.go"
+)
+
+// SpoolConfig configures the disk spool of a publisher
+type SpoolConfig struct {
+	// Dir holds the segment files; it is created if needed. Publishers must not share it;
+	// NewPublisherPool gives each of its publishers a subdirectory.
+	Dir string
+	// MaxBytes caps the spool on disk; publishes fail once it is full. Defaults to 256 MB.
+	MaxBytes int64
+	// SegmentBytes is the size of a segment file; defaults to 8 MB
+	SegmentBytes int64
+}
+
+const (
+	// spoolReplayBatch is the number of replayed notifications flushed at once
+	spoolReplayBatch = 100
+	// spoolFlushTimeout bounds the wait for the server to receive a replayed batch
+	spoolFlushTimeout = 5 * time.Second
+)
+
+// SpoolMetrics can be implemented by Metrics to also observe the spool
+type SpoolMetrics interface {
+	// ObserveSpooled is called for every notification written to the spool
+	ObserveSpooled(subject string)
+	// ObserveReplayed is called for every spooled notification published after reconnecting
+	ObserveReplayed(subject string)
+}
+
+// SpoolStats describes the activity of a publisher's spool
+type SpoolStats struct {
+	// Spooled and Replayed count notifications since the publisher was created
+	Spooled  uint64
+	Replayed uint64
+	// Corrupted counts segment tails skipped because of corruption
+	Corrupted uint64
+	// Bytes is the size of the spool on disk
+	Bytes    int64
+	Segments int
+}
+
+// publisherSpool spools notifications published while disconnected
+type publisherSpool struct {
+	store     *spool.Spool
+	replaying atomic.Bool
+	spooled   atomic.Uint64
+	replayed  atomic.Uint64
+}
+
+// WithSpool writes notifications published while disconnected to disk instead of the
+// reconnect buffer and replays them in order once connected, so long outages lose
+// nothing. Replayed notifications are removed from disk once the server received them;
+// they survive restarts, and some may be published twice when the process stops or the
+// connection drops during a replay. Order is kept for notifications published one after
+// another: notifications published concurrently while the connection drops may be
+// published in either order, as without a spool.
+func WithSpool(config SpoolConfig) Option {
+	return func(o *publisherOptions) {
+		o.spool = &config
+	}
+}
+
+func (c *SpoolConfig) validate() error {
+	if c.Dir == "" {
+		return notification.NewError(notification.InvalidArguments, "spool directory cannot be empty")
+	}
+	if c.MaxBytes < 0 || c.SegmentBytes < 0 {
+		return notification.NewError(notification.InvalidArguments, "spool sizes cannot be negative")
+	}
+	return nil
+}
+
+func openSpool(config *SpoolConfig) (*publisherSpool, error) {
+	store, err := spool.Open(config.Dir, spool.Options{MaxBytes: config.MaxBytes, SegmentBytes: config.SegmentBytes})
+	if err != nil {
+		return nil, err
+	}
+	return &publisherSpool{store: store}, nil
+}
+
+// startSpool replays the spool whenever the publisher connects
+func (p *Publisher) startSpool() {
+	p.events.add(func(event ConnectionEvent) {
+		if event.Kind == EventConnected || event.Kind == EventReconnected {
+			go p.replaySpool()
+		}
+	})
+	// The connect may have happened before the handler was added
+	if p.nc.IsConnected() {
+		go p.replaySpool()
+	}
+}
+
+// spoolIfDisconnected spools a notification while the publisher is disconnected, and
+// while older notifications are still spooled so order is kept
+func (p *Publisher) spoolIfDisconnected(subject string, data []byte) (bool, error) {
+	if p.nc.IsClosed() || (p.nc.IsConnected() && p.spool.store.Empty()) {
+		return false, nil
+	}
+	return true, p.spoolRecord(subject, data)
+}
+
+func (p *Publisher) spoolRecord(subject string, data []byte) error {
+	if err := p.spool.store.Append(encodeSpoolRecord(subject, data)); err != nil {
+		p.logger.Error("failed to spool notification", "subject", subject, "error", err)
+		return notification.NewError(notification.Internal, "failed to spool notification: "+err.Error())
+	}
+	p.spool.spooled.Add(1)
+	if m, ok := p.metrics.(SpoolMetrics); ok {
+		m.ObserveSpooled(subject)
+	}
+
+	// Reconnected while spooling; make sure this record is not left behind
+	if p.nc.IsConnected() {
+		go p.replaySpool()
+	}
+	return nil
+}
+
+// replaySpool publishes spooled notifications in order until the spool is empty, the
+// connection drops or the publisher shuts down. Only one replay runs at a time.
+func (p *Publisher) replaySpool() {
+	for {
+		if !p.spool.replaying.CompareAndSwap(false, true) {
+			return
+		}
+		if err := p.begin(); err != nil {
+			p.spool.replaying.Store(false)
+			return
+		}
+
+		var batch []string
+		replayed, err := p.spool.store.Replay(spoolReplayBatch, func(record []byte) error {
+			if !p.nc.IsConnected() {
+				return errNotConnected
+			}
+			if p.isClosed() {
+				// Leave the rest on disk for the next start
+				return ErrPublisherClosed
+			}
+			subject, data, ok := decodeSpoolRecord(record)
+			if !ok {
+				p.logger.Error("skipped malformed spool record")
+				return nil
+			}
+			if err := p.nc.Publish(subject, data); err != nil {
+				return err
+			}
+			batch = append(batch, subject)
+			return nil
+		}, func() error {
+			// Publish only buffers; the batch stays on disk until the server has it
+			defer func() { batch = batch[:0] }()
+			if err := p.nc.FlushTimeout(spoolFlushTimeout); err != nil {
+				if !p.nc.IsConnected() {
+					return errNotConnected
+				}
+				return err
+			}
+			p.spool.replayed.Add(uint64(len(batch)))
+			if m, ok := p.metrics.(SpoolMetrics); ok {
+				for _, subject := range batch {
+					m.ObserveReplayed(subject)
+				}
+			}
+			return nil
+		})
+		if errors.Is(err, spool.ErrClosed) {
+			// Close ran during the replay; the rest stays on disk as well
+			err = ErrPublisherClosed
+		}
+		if replayed > 0 {
+			p.logger.Info("replayed spooled notifications", "count", replayed)
+		}
+		if err != nil && !errors.Is(err, errNotConnected) && !errors.Is(err, ErrPublisherClosed) {
+			p.logger.Error("failed to replay spooled notifications", "error", err)
+		}
+
+		p.end()
+		p.spool.replaying.Store(false)
+		// A record spooled after the replay finished would otherwise wait for the next reconnect
+		if err != nil || !p.nc.IsConnected() || p.spool.store.Empty() {
+			return
+		}
+	}
+}
+
+// closeSpool closes the spool files; unreplayed notifications stay on disk
+func (p *Publisher) closeSpool() {
+	if p.spool == nil {
+		return
+	}
+	if err := p.spool.store.Close(); err != nil {
+		p.logger.Error("failed to close spool", "error", err)
+	}
+}
+
+// SpoolStats returns the activity of the spool; the zero value without WithSpool
+func (p *Publisher) SpoolStats() SpoolStats {
+	if p.spool == nil {
+		return SpoolStats{}
+	}
+	stats := p.spool.store.Stats()
+	return SpoolStats{
+		Spooled:   p.spool.spooled.Load(),
+		Replayed:  p.spool.replayed.Load(),
+		Corrupted: stats.Corrupted,
+		Bytes:     stats.Bytes,
+		Segments:  stats.Segments,
+	}
+}
+
+// encodeSpoolRecord stores the subject, prefixed by its length, before the payload
+func encodeSpoolRecord(subject string, data []byte) []byte {
+	record := binary.AppendUvarint(make([]byte, 0, binary.MaxVarintLen64+len(subject)+len(data)), uint64(len(subject)))
+	record = append(record, subject...)
+	return append(record, data...)
+}
+
+func decodeSpoolRecord(record []byte) (subject string, data []byte, ok bool) {
+	n, size := binary.Uvarint(record)
+	if size <= 0 || n > uint64(len(record)-size) {
+		return "", nil, false
+	}
+	return string(record[size : size+int(n)]), record[size+int(n):], true
+}
+
+// spoolable reports whether a failed publish should be spooled instead
+func (p *Publisher) spoolable(err error) bool {
+	return p.spool != nil && errors.Is(err, nats.ErrReconnectBufExceeded)
+}
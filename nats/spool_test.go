@@ -0,0 +1,159 @@
+package nats
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type spoolMetrics struct {
+	published atomic.Int32
+	spooled   atomic.Int32
+	replayed  atomic.Int32
+}
+
+func (m *spoolMetrics) ObservePublish(_ string, _ time.Duration, err error) {
+	if err == nil {
+		m.published.Add(1)
+	}
+}
+
+func (m *spoolMetrics) ObserveSpooled(string)  { m.spooled.Add(1) }
+func (m *spoolMetrics) ObserveReplayed(string) { m.replayed.Add(1) }
+
+func startServerOnPort(t *testing.T, port int) *server.Server {
+	t.Helper()
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: port, NoLog: true, NoSigs: true})
+	require.NoError(t, err)
+	go s.Start()
+	require.True(t, s.ReadyForConnections(5*time.Second))
+	return s
+}
+
+func TestSpool(t *testing.T) {
+	port := freePort(t)
+	url := "nats://127.0.0.1:" + strconv.Itoa(port)
+	s := startServerOnPort(t, port)
+
+	metrics := &spoolMetrics{}
+	events := make(chan ConnectionEvent, 16)
+	// The publisher reconnects slowly enough for the test to subscribe first
+	publisher, err := New(
+		WithURL(url),
+		WithSubjectPrefix("test-spool"),
+		WithJetStream(false),
+		WithSpool(SpoolConfig{Dir: t.TempDir(), SegmentBytes: 1024}),
+		WithMetrics(metrics),
+		WithEventHandler(func(event ConnectionEvent) { events <- event }),
+		WithNATSOptions(nats.ReconnectWait(500*time.Millisecond), nats.ReconnectJitter(0, 0)),
+	)
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	s.Shutdown()
+	nextEvent(t, events, EventDisconnected)
+
+	const count = 30
+	for i := 0; i < count; i++ {
+		require.NoError(t, publisher.PublishNotification("client-1", "Spooled", strconv.Itoa(i), notification.TypeInfo, "system"))
+	}
+	stats := publisher.SpoolStats()
+	assert.EqualValues(t, count, stats.Spooled)
+	assert.Positive(t, stats.Bytes)
+	assert.Greater(t, stats.Segments, 1)
+	assert.EqualValues(t, count, metrics.spooled.Load())
+	assert.EqualValues(t, count, metrics.published.Load())
+	assert.Contains(t, publisher.CheckHealth(context.Background()).Details, "spooled_bytes")
+
+	s = startServerOnPort(t, port)
+	defer s.Shutdown()
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+	sub, err := nc.SubscribeSync("test-spool.*")
+	require.NoError(t, err)
+	require.NoError(t, nc.Flush())
+
+	// Spooled notifications arrive in order, before newer ones
+	nextEvent(t, events, EventReconnected)
+	require.NoError(t, publisher.PublishNotification("client-1", "Live", "after", notification.TypeInfo, "system"))
+	for i := 0; i < count; i++ {
+		msg, err := sub.NextMsg(2 * time.Second)
+		require.NoError(t, err)
+		assert.Contains(t, string(msg.Data), `"message":"`+strconv.Itoa(i)+`"`)
+	}
+	msg, err := sub.NextMsg(2 * time.Second)
+	require.NoError(t, err)
+	assert.Contains(t, string(msg.Data), `"message":"after"`)
+
+	require.Eventually(t, func() bool { return publisher.SpoolStats().Bytes == 0 }, 2*time.Second, 10*time.Millisecond)
+	// The live notification is spooled too when the replay has not finished yet
+	stats = publisher.SpoolStats()
+	assert.Equal(t, stats.Spooled, stats.Replayed)
+	assert.Zero(t, stats.Segments)
+	assert.Equal(t, metrics.spooled.Load(), metrics.replayed.Load())
+}
+
+func TestSpoolSurvivesRestart(t *testing.T) {
+	port := freePort(t)
+	url := "nats://127.0.0.1:" + strconv.Itoa(port)
+	dir := t.TempDir()
+
+	// Nothing is listening yet, so every publish is spooled
+	publisher, err := New(WithURL(url), WithSubjectPrefix("test-spool"), WithJetStream(false), WithLazyConnect(), WithSpool(SpoolConfig{Dir: dir}))
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, publisher.PublishNotification("client-1", "Spooled", strconv.Itoa(i), notification.TypeInfo, "system"))
+	}
+	require.NoError(t, publisher.Shutdown(context.Background()))
+
+	s := startServerOnPort(t, port)
+	defer s.Shutdown()
+	nc, err := nats.Connect(url)
+	require.NoError(t, err)
+	defer nc.Close()
+	sub, err := nc.SubscribeSync("test-spool.*")
+	require.NoError(t, err)
+	require.NoError(t, nc.Flush())
+
+	publisher, err = New(WithURL(url), WithSubjectPrefix("test-spool"), WithJetStream(false), WithSpool(SpoolConfig{Dir: dir}))
+	require.NoError(t, err)
+	defer publisher.Close()
+	for i := 0; i < 3; i++ {
+		msg, err := sub.NextMsg(2 * time.Second)
+		require.NoError(t, err)
+		assert.Contains(t, string(msg.Data), `"message":"`+strconv.Itoa(i)+`"`)
+	}
+}
+
+func TestSpoolFull(t *testing.T) {
+	publisher, err := New(WithURL("nats://127.0.0.1:"+strconv.Itoa(freePort(t))), WithJetStream(false), WithLazyConnect(),
+		WithSpool(SpoolConfig{Dir: t.TempDir(), MaxBytes: 512}))
+	require.NoError(t, err)
+	defer publisher.Close()
+
+	var publishErr error
+	for i := 0; i < 10 && publishErr == nil; i++ {
+		publishErr = publisher.PublishNotification("client-1", "Spooled", "Message", notification.TypeInfo, "system")
+	}
+	assert.ErrorContains(t, publishErr, "spool is full")
+	assert.EqualValues(t, notification.Internal, publishErr.(*notification.Error).Code)
+}
+
+func TestSpoolRecord(t *testing.T) {
+	subject, data, ok := decodeSpoolRecord(encodeSpoolRecord("notifications.client-1", []byte(`{"id":"1"}`)))
+	require.True(t, ok)
+	assert.Equal(t, "notifications.client-1", subject)
+	assert.Equal(t, `{"id":"1"}`, string(data))
+
+	_, _, ok = decodeSpoolRecord([]byte{0x40, 'a'})
+	assert.False(t, ok)
+}
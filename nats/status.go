@@ -0,0 +1,196 @@
+package nats
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MyWeHub/notification-sdk/internal/natsutil"
+	"github.com/MyWeHub/notification-sdk/internal/utils"
+	"github.com/MyWeHub/notification-sdk/internal/validation"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats.go"
+)
+
+// WithStatusReporting makes the publisher emit a published status event for every
+// notification it publishes
+func WithStatusReporting() Option {
+	return func(o *publisherOptions) {
+		o.reportStatus = true
+	}
+}
+
+// ReportStatus publishes a delivery status event on the notification's status subject
+func (p *Publisher) ReportStatus(event *notification.StatusEvent) error {
+	if err := p.begin(); err != nil {
+		return err
+	}
+	defer p.end()
+	return p.reportStatusEvent(event)
+}
+
+// reportStatusEvent publishes a status event for a publish that is already in flight
+func (p *Publisher) reportStatusEvent(event *notification.StatusEvent) error {
+	if err := validation.ValidateStatusEvent(event); err != nil {
+		return err
+	}
+
+	// Fill in defaults on a copy; the caller's event is left untouched
+	stamped := *event
+	event = &stamped
+	if utils.IsZeroTime(event.Timestamp) {
+		event.Timestamp = p.now()
+	}
+
+	data, err := utils.MarshalStatusEvent(event)
+	if err != nil {
+		return err
+	}
+
+	subject := natsutil.BuildStatusSubject(p.subjectPrefix, event.NotificationID)
+	if err := natsutil.ValidatePublishSubject(subject); err != nil {
+		return err
+	}
+	if err := p.nc.Publish(subject, data); err != nil {
+		return notification.NewError(notification.Internal, "failed to publish status event: "+err.Error())
+	}
+
+	return nil
+}
+
+// StatusTracker collects status events published under a subject prefix into a status store
+type StatusTracker struct {
+	nc            *nats.Conn
+	subjectPrefix string
+	store         notification.StatusStorePort
+
+	mu           sync.Mutex
+	sub          *nats.Subscription
+	errorHandler func(error)
+}
+
+// NewStatusTracker creates a tracker recording the status events published under subjectPrefix
+func NewStatusTracker(natsURL, subjectPrefix string, store notification.StatusStorePort) (*StatusTracker, error) {
+	return newStatusTracker(natsURL, subjectPrefix, store, nil)
+}
+
+// NewSecureStatusTracker creates a status tracker authenticating with a security
+// configuration. The configuration is validated before connecting.
+func NewSecureStatusTracker(natsURL, subjectPrefix string, store notification.StatusStorePort, security SecurityConfig) (*StatusTracker, error) {
+	return newStatusTracker(natsURL, subjectPrefix, store, &security)
+}
+
+func newStatusTracker(natsURL, subjectPrefix string, store notification.StatusStorePort, security *SecurityConfig) (*StatusTracker, error) {
+	if store == nil {
+		return nil, notification.NewError(notification.InvalidArguments, "status store cannot be nil")
+	}
+
+	nc, err := natsutil.ConnectSecure(natsURL, security, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatusTracker{
+		nc:            nc,
+		subjectPrefix: subjectPrefix,
+		store:         store,
+	}, nil
+}
+
+// OnError sets the handler called for status events that cannot be decoded or stored;
+// it must be set before Start
+func (t *StatusTracker) OnError(handler func(error)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.errorHandler = handler
+}
+
+// Start subscribes to the status subjects and begins recording events
+func (t *StatusTracker) Start() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.sub != nil {
+		return notification.NewError(notification.AlreadyExists, "status tracker already started")
+	}
+
+	subject := t.subjectPrefix + "." + natsutil.StatusToken + ".>"
+	if err := natsutil.ValidateSubscribeSubject(subject); err != nil {
+		return err
+	}
+	handleError := t.errorHandler
+	sub, err := t.nc.Subscribe(subject, func(msg *nats.Msg) {
+		event, err := utils.UnmarshalStatusEvent(msg.Data)
+		if err == nil {
+			err = validation.ValidateStatusEvent(event)
+		}
+		if err == nil {
+			err = t.store.AppendStatus(context.Background(), event)
+		}
+		if err != nil && handleError != nil {
+			handleError(err)
+		}
+	})
+	if err != nil {
+		return notification.NewError(notification.Internal, "failed to subscribe to "+subject+": "+err.Error())
+	}
+
+	t.sub = sub
+	return nil
+}
+
+// Timeline returns the status events recorded for a notification in chronological order
+func (t *StatusTracker) Timeline(ctx context.Context, notificationID string) ([]notification.StatusEvent, error) {
+	if notificationID == "" {
+		return nil, notification.NewError(notification.InvalidArguments, "notificationID cannot be empty")
+	}
+	return t.store.GetTimeline(ctx, notificationID)
+}
+
+// Close stops recording and closes the NATS connection
+func (t *StatusTracker) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.sub != nil {
+		if err := t.sub.Unsubscribe(); err != nil && err != nats.ErrConnectionClosed {
+			return notification.NewError(notification.Internal, "failed to unsubscribe status tracker: "+err.Error())
+		}
+		t.sub = nil
+	}
+	if t.nc != nil {
+		t.nc.Close()
+	}
+	return nil
+}
+
+// StatusRecorder turns dispatcher delivery outcomes into delivered/failed status events
+type StatusRecorder struct {
+	reporter notification.StatusReporterPort
+	name     string
+}
+
+// NewStatusRecorder creates a delivery recorder reporting outcomes through reporter under the given name
+func NewStatusRecorder(reporter notification.StatusReporterPort, name string) *StatusRecorder {
+	return &StatusRecorder{
+		reporter: reporter,
+		name:     name,
+	}
+}
+
+// RecordDelivery reports a delivery outcome as a status event
+func (r *StatusRecorder) RecordDelivery(_ context.Context, outcome notification.DeliveryOutcome) error {
+	event := &notification.StatusEvent{
+		NotificationID: outcome.NotificationID,
+		Channel:        outcome.Channel,
+		Status:         notification.StatusDelivered,
+		Reporter:       r.name,
+		Timestamp:      outcome.AttemptedAt.Add(outcome.Duration),
+	}
+	if !outcome.Delivered {
+		event.Status = notification.StatusFailed
+		event.Error = outcome.Error
+	}
+
+	return r.reporter.ReportStatus(event)
+}
@@ -0,0 +1,122 @@
+package nats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/MyWeHub/notification-sdk/memory"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStatusReporter struct {
+	events []*notification.StatusEvent
+}
+
+func (r *fakeStatusReporter) ReportStatus(event *notification.StatusEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func TestStatusRecorder(t *testing.T) {
+	reporter := &fakeStatusReporter{}
+	recorder := NewStatusRecorder(reporter, "dispatcher")
+
+	at := time.Now().UTC()
+	assert.NoError(t, recorder.RecordDelivery(context.Background(), notification.DeliveryOutcome{
+		NotificationID: "n-1", Channel: notification.ChannelEmail, Delivered: true, AttemptedAt: at, Duration: time.Second,
+	}))
+	assert.NoError(t, recorder.RecordDelivery(context.Background(), notification.DeliveryOutcome{
+		NotificationID: "n-1", Channel: notification.ChannelSMS, Error: "carrier rejected", AttemptedAt: at,
+	}))
+
+	assert.Len(t, reporter.events, 2)
+	assert.Equal(t, notification.StatusDelivered, reporter.events[0].Status)
+	assert.Equal(t, at.Add(time.Second), reporter.events[0].Timestamp)
+	assert.Equal(t, "dispatcher", reporter.events[0].Reporter)
+	assert.Equal(t, notification.StatusFailed, reporter.events[1].Status)
+	assert.Equal(t, "carrier rejected", reporter.events[1].Error)
+}
+
+func TestStatusTracking(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	tracker, err := NewStatusTracker(nats.DefaultURL, "test-status", memory.NewStatusStore())
+	if err != nil {
+		t.Fatalf("Failed to create status tracker: %v", err)
+	}
+	defer tracker.Close()
+
+	if err := tracker.Start(); err != nil {
+		t.Fatalf("Failed to start status tracker: %v", err)
+	}
+	if err := tracker.nc.Flush(); err != nil {
+		t.Fatalf("Failed to flush connection: %v", err)
+	}
+
+	publisher, err := New(WithURL(nats.DefaultURL), WithSubjectPrefix("test-status"), WithStatusReporting())
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	notif := &notification.Notification{
+		ID:       "status-test-notification",
+		ClientID: "test-client",
+		Title:    "Test Title",
+		Message:  "Test message",
+		Source:   "system",
+	}
+	if err := publisher.PublishCustomNotification("test-client", notif); err != nil {
+		t.Fatalf("Failed to publish notification: %v", err)
+	}
+	read := &notification.StatusEvent{
+		NotificationID: notif.ID,
+		Channel:        notification.ChannelInApp,
+		Status:         notification.StatusRead,
+	}
+	if err := publisher.ReportStatus(read); err != nil {
+		t.Fatalf("Failed to report status: %v", err)
+	}
+	assert.True(t, read.Timestamp.IsZero(), "the caller's event is not modified")
+
+	var timeline []notification.StatusEvent
+	assert.Eventually(t, func() bool {
+		timeline, _ = tracker.Timeline(context.Background(), notif.ID)
+		return len(timeline) == 2
+	}, 3*time.Second, 20*time.Millisecond)
+	assert.Equal(t, notification.StatusPublished, timeline[0].Status)
+	assert.Equal(t, "system", timeline[0].Reporter)
+	assert.Equal(t, notification.StatusRead, timeline[1].Status)
+
+	err = publisher.ReportStatus(&notification.StatusEvent{NotificationID: notif.ID, Status: "bounced"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unknown status")
+}
+
+func TestStatusTrackerErrorHandler(t *testing.T) {
+	s := startServer(t)
+
+	errs := make(chan error, 1)
+	tracker, err := NewStatusTracker(s.ClientURL(), "test-status", memory.NewStatusStore())
+	require.NoError(t, err)
+	defer tracker.Close()
+	tracker.OnError(func(err error) { errs <- err })
+	require.NoError(t, tracker.Start())
+	require.NoError(t, tracker.nc.Flush())
+
+	require.NoError(t, tracker.nc.Publish("test-status.status.n-1", []byte(`{"notification_id":"n-1","status":"bounced"}`)))
+	select {
+	case err := <-errs:
+		assert.ErrorContains(t, err, "unknown status")
+	case <-time.After(2 * time.Second):
+		t.Fatal("error handler was not called")
+	}
+}
@@ -0,0 +1,103 @@
+package nats
+
+import (
+	"github.com/MyWeHub/notification-sdk/internal/natsutil"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats.go"
+)
+
+// Subject templates. DefaultSubjectTemplate publishes on <prefix>.<client>;
+// HierarchicalSubjectTemplate adds user, type and source tokens so subscribers can
+// filter, e.g. on every error notification of a client.
+const (
+	DefaultSubjectTemplate      = natsutil.DefaultSubjectTemplate
+	HierarchicalSubjectTemplate = natsutil.HierarchicalSubjectTemplate
+)
+
+// EmptyToken is published in place of empty subject components such as a missing user ID
+const EmptyToken = natsutil.EmptyToken
+
+// MaxSubjectLength is the longest subject, in bytes, the validators accept
+const MaxSubjectLength = natsutil.MaxSubjectLength
+
+// LegacySubjectHeader marks the copies WithLegacySubjects publishes on legacy subjects
+const LegacySubjectHeader = "Notification-Legacy-Subject"
+
+// SubjectScheme builds, parses and matches notification subjects
+type SubjectScheme = natsutil.SubjectScheme
+
+// SubjectParts are the components of a notification subject
+type SubjectParts = natsutil.SubjectParts
+
+// NewSubjectScheme creates a subject scheme from a template using the placeholders
+// {prefix}, {client}, {user}, {type} and {source}, e.g. "{prefix}.{client}.{type}"
+func NewSubjectScheme(template, subjectPrefix string) (*SubjectScheme, error) {
+	return natsutil.NewSubjectScheme(template, subjectPrefix)
+}
+
+// WithSubjectTemplate sets the template the publisher builds notification subjects from;
+// defaults to DefaultSubjectTemplate
+func WithSubjectTemplate(template string) Option {
+	return func(o *publisherOptions) {
+		o.subjectTemplate = template
+	}
+}
+
+// SubjectScheme returns the scheme the publisher builds notification subjects with
+func (p *Publisher) SubjectScheme() *SubjectScheme {
+	return p.scheme
+}
+
+// EncodeSubjectToken encodes a value such as a client ID as a single subject token.
+// Whitespace, control characters, '.', '*', '>' and '%' are escaped as %XX, so the
+// encoding is reversible and distinct values never share a subject; other values are
+// published unchanged, as in earlier versions.
+func EncodeSubjectToken(value string) string {
+	return natsutil.EncodeSubjectToken(value)
+}
+
+// DecodeSubjectToken recovers the value an encoded subject token was built from
+func DecodeSubjectToken(token string) (string, error) {
+	return natsutil.DecodeSubjectToken(token)
+}
+
+// ValidatePublishSubject checks a subject against the NATS subject grammar for publishing,
+// describing the first problem found in an InvalidArguments error
+func ValidatePublishSubject(subject string) error {
+	return natsutil.ValidatePublishSubject(subject)
+}
+
+// ValidateSubscribeSubject checks a subject, which may contain wildcards, against the
+// NATS subject grammar for subscribing
+func ValidateSubscribeSubject(subject string) error {
+	return natsutil.ValidateSubscribeSubject(subject)
+}
+
+// WithLegacySubjects makes the publisher also publish every notification on the subject
+// the previous, lossy encoding produced, where it differs, so subscribers can be migrated
+// one at a time. Legacy copies carry LegacySubjectHeader; drop the option once every
+// subscriber uses the new encoding.
+func WithLegacySubjects() Option {
+	return func(o *publisherOptions) {
+		o.legacy = true
+	}
+}
+
+func (p *Publisher) publishLegacy(subject string, data []byte) error {
+	legacy := natsutil.LegacySubject(p.subjectPrefix, subject)
+	if legacy == subject {
+		return nil
+	}
+	if err := natsutil.ValidatePublishSubject(legacy); err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(legacy)
+	msg.Header.Set(LegacySubjectHeader, subject)
+	msg.Data = data
+	if err := p.nc.PublishMsg(msg); err != nil {
+		return notification.NewError(notification.Internal, "failed to publish notification on legacy subject: "+err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package nats
+
+import (
+	"context"
+	"sync"
+
+	"github.com/MyWeHub/notification-sdk/internal/natsutil"
+	"github.com/MyWeHub/notification-sdk/internal/validation"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats.go"
+)
+
+// TenantSubjectPrefix returns the subject namespace of a tenant without an explicit
+// SubjectPrefix: <subjectPrefix>.tenant.<tenantID>. Client IDs cannot be "tenant", so the
+// namespace never overlaps a client's subjects.
+func TenantSubjectPrefix(subjectPrefix, tenantID string) string {
+	return subjectPrefix + "." + natsutil.TenantToken + "." + natsutil.EncodeSubjectToken(tenantID)
+}
+
+// TenantPublishers hands out publishers isolated per tenant. Each tenant gets its own
+// connection, authenticated with the tenant's credentials when it has any, and its own
+// subject namespace. For hard isolation give every tenant a NATS account whose users may
+// only publish below the tenant's namespace.
+type TenantPublishers struct {
+	natsURL       string
+	subjectPrefix string
+	registry      notification.TenantRegistryPort
+	opts          []nats.Option
+
+	mu      sync.Mutex
+	tenants map[string]*tenantEntry
+	closed  bool
+}
+
+// tenantEntry is the connection of a tenant, shared by every TenantPublisher handed out
+// for it. ready is closed once the connection attempt finished; canceled reports that it
+// failed because the context of the caller that dialed ended.
+type tenantEntry struct {
+	ready     chan struct{}
+	publisher *Publisher
+	err       error
+	canceled  bool
+	refs      int
+}
+
+// NewTenantPublishers creates tenant publishers resolving tenants from registry. The
+// options apply to every tenant connection.
+func NewTenantPublishers(natsURL, subjectPrefix string, registry notification.TenantRegistryPort, opts ...nats.Option) (*TenantPublishers, error) {
+	if registry == nil {
+		return nil, notification.NewError(notification.InvalidArguments, "tenant registry cannot be nil")
+	}
+	if err := natsutil.ValidatePublishSubject(subjectPrefix); err != nil {
+		return nil, err
+	}
+
+	return &TenantPublishers{
+		natsURL:       natsURL,
+		subjectPrefix: subjectPrefix,
+		registry:      registry,
+		opts:          opts,
+		tenants:       make(map[string]*tenantEntry),
+	}, nil
+}
+
+// For returns a publisher of a tenant, connecting it on first use. Publishers of the same
+// tenant share its connection, which is closed once every one of them is closed. A tenant
+// that is slow to connect does not hold up the others. ctx bounds the connect retries; when
+// it ends, callers waiting on the same tenant dial again with their own context.
+func (t *TenantPublishers) For(ctx context.Context, tenantID string) (*TenantPublisher, error) {
+	if err := validation.ValidateTenantID(tenantID); err != nil {
+		return nil, err
+	}
+
+	for {
+		t.mu.Lock()
+		if t.closed {
+			t.mu.Unlock()
+			return nil, notification.NewError(notification.Internal, "tenant publishers closed")
+		}
+		entry, ok := t.tenants[tenantID]
+		if !ok {
+			entry = &tenantEntry{ready: make(chan struct{})}
+			t.tenants[tenantID] = entry
+		}
+		t.mu.Unlock()
+
+		if !ok {
+			t.dial(ctx, tenantID, entry)
+		}
+		select {
+		case <-entry.ready:
+		case <-ctx.Done():
+			return nil, notification.NewError(notification.Internal, "waiting for tenant "+tenantID+" to connect: "+ctx.Err().Error())
+		}
+		if entry.err != nil {
+			if entry.canceled && ctx.Err() == nil {
+				// Another caller gave up; dial again with this caller's context
+				continue
+			}
+			return nil, entry.err
+		}
+
+		t.mu.Lock()
+		// The last publisher of the tenant may have been closed in the meantime
+		if t.tenants[tenantID] == entry {
+			entry.refs++
+			t.mu.Unlock()
+			return t.wrap(tenantID, entry), nil
+		}
+		t.mu.Unlock()
+	}
+}
+
+// dial connects a tenant outside t.mu, so other tenants stay available meanwhile
+func (t *TenantPublishers) dial(ctx context.Context, tenantID string, entry *tenantEntry) {
+	publisher, err := t.connect(ctx, tenantID)
+
+	t.mu.Lock()
+	if err == nil && t.closed {
+		publisher.Close()
+		err = notification.NewError(notification.Internal, "tenant publishers closed")
+	}
+	if err != nil && t.tenants[tenantID] == entry {
+		// Let the next call try again
+		delete(t.tenants, tenantID)
+	}
+	entry.publisher, entry.err = publisher, err
+	entry.canceled = err != nil && ctx.Err() != nil
+	close(entry.ready)
+	t.mu.Unlock()
+}
+
+func (t *TenantPublishers) connect(ctx context.Context, tenantID string) (*Publisher, error) {
+	tenant, err := t.registry.GetTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	subjectPrefix := tenant.SubjectPrefix
+	if subjectPrefix == "" {
+		subjectPrefix = TenantSubjectPrefix(t.subjectPrefix, tenant.ID)
+	}
+	if err := natsutil.ValidatePublishSubject(subjectPrefix); err != nil {
+		return nil, err
+	}
+
+	natsOpts := append([]nats.Option{nats.Name("notification-sdk tenant " + tenant.ID)}, t.opts...)
+	if tenant.CredentialsFile != "" {
+		natsOpts = append(natsOpts, nats.UserCredentials(tenant.CredentialsFile))
+	}
+
+	return NewWithContext(ctx, WithURL(t.natsURL), WithSubjectPrefix(subjectPrefix), WithNATSOptions(natsOpts...))
+}
+
+func (t *TenantPublishers) wrap(tenantID string, entry *tenantEntry) *TenantPublisher {
+	return &TenantPublisher{
+		tenantID:  tenantID,
+		registry:  t.registry,
+		publisher: entry.publisher,
+		owner:     t,
+		entry:     entry,
+	}
+}
+
+// release drops a reference to a tenant's connection, closing it with the last one
+func (t *TenantPublishers) release(tenantID string, entry *tenantEntry) error {
+	t.mu.Lock()
+	entry.refs--
+	last := entry.refs == 0 && t.tenants[tenantID] == entry
+	if last {
+		delete(t.tenants, tenantID)
+	}
+	t.mu.Unlock()
+
+	if !last {
+		return nil
+	}
+	return entry.publisher.Close()
+}
+
+// Close closes the connections of every tenant, including those still in use
+func (t *TenantPublishers) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closed = true
+	for tenantID, entry := range t.tenants {
+		select {
+		case <-entry.ready:
+			if entry.publisher != nil {
+				entry.publisher.Close()
+			}
+		default:
+			// Closed by dial once it connects
+		}
+		delete(t.tenants, tenantID)
+	}
+	return nil
+}
+
+// TenantPublisher publishes notifications on behalf of a single tenant and rejects
+// notifications addressed to clients of other tenants
+type TenantPublisher struct {
+	tenantID  string
+	registry  notification.TenantRegistryPort
+	publisher *Publisher
+	owner     *TenantPublishers
+	entry     *tenantEntry
+	closeOnce sync.Once
+}
+
+var _ notification.PublisherPort = (*TenantPublisher)(nil)
+
+// TenantID returns the tenant the publisher publishes for
+func (p *TenantPublisher) TenantID() string {
+	return p.tenantID
+}
+
+// SubjectScheme returns the scheme the tenant's notification subjects are built with
+func (p *TenantPublisher) SubjectScheme() *SubjectScheme {
+	return p.publisher.SubjectScheme()
+}
+
+// PublishNotification publishes a notification to a client of the tenant
+func (p *TenantPublisher) PublishNotification(clientID string, title string, message string, notificationType notification.NotificationType, source string) error {
+	if err := p.checkClient(clientID); err != nil {
+		return err
+	}
+	return p.publisher.PublishNotification(clientID, title, message, notificationType, source)
+}
+
+// PublishCustomNotification publishes a custom notification to a client of the tenant
+func (p *TenantPublisher) PublishCustomNotification(clientID string, notif *notification.Notification) error {
+	if err := p.checkClient(clientID); err != nil {
+		return err
+	}
+	if notif != nil && notif.ClientID != "" && notif.ClientID != clientID {
+		if err := p.checkClient(notif.ClientID); err != nil {
+			return err
+		}
+	}
+	return p.publisher.PublishCustomNotification(clientID, notif)
+}
+
+// Close releases the publisher. The tenant's connection is closed once every publisher
+// of the tenant is closed; the next call to For reconnects it.
+func (p *TenantPublisher) Close() error {
+	var err error
+	p.closeOnce.Do(func() {
+		err = p.owner.release(p.tenantID, p.entry)
+	})
+	return err
+}
+
+func (p *TenantPublisher) checkClient(clientID string) error {
+	if err := validation.ValidateClientID(clientID); err != nil {
+		return err
+	}
+
+	owns, err := p.registry.OwnsClient(context.Background(), p.tenantID, clientID)
+	if err != nil {
+		return err
+	}
+	if !owns {
+		return notification.NewError(notification.PermissionDenied, "client "+clientID+" does not belong to tenant "+p.tenantID)
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package nats
+
+import (
+	"context"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/MyWeHub/notification-sdk/memory"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantPublishers(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	registry := memory.NewTenantRegistry()
+	assert.NoError(t, registry.AddTenant(notification.Tenant{ID: "acme"}))
+	assert.NoError(t, registry.AddTenant(notification.Tenant{ID: "globex", SubjectPrefix: "test-globex"}))
+	assert.NoError(t, registry.AddClient("acme", "client-1"))
+	assert.NoError(t, registry.AddClient("globex", "client-2"))
+
+	tenants, err := NewTenantPublishers(nats.DefaultURL, "test-tenants", registry)
+	if err != nil {
+		t.Fatalf("Failed to create tenant publishers: %v", err)
+	}
+	defer tenants.Close()
+
+	ch := make(chan *nats.Msg, 4)
+	subscription, err := nc.ChanSubscribe("test-tenants.tenant.*.*", ch)
+	if err != nil {
+		t.Fatalf("Failed to subscribe to NATS: %v", err)
+	}
+	defer subscription.Unsubscribe()
+	if err := nc.Flush(); err != nil {
+		t.Fatalf("Failed to flush connection: %v", err)
+	}
+
+	ctx := context.Background()
+	_, err = tenants.For(ctx, "initech")
+	assert.Error(t, err)
+
+	acme, err := tenants.For(ctx, "acme")
+	if err != nil {
+		t.Fatalf("Failed to get tenant publisher: %v", err)
+	}
+	assert.Equal(t, "acme", acme.TenantID())
+
+	err = acme.PublishNotification("client-2", "Title", "Message", notification.TypeInfo, "system")
+	if assert.Error(t, err) {
+		assert.EqualValues(t, notification.PermissionDenied, err.(*notification.Error).Code)
+	}
+	err = acme.PublishCustomNotification("client-1", &notification.Notification{
+		ClientID: "client-2",
+		Title:    "Title",
+		Message:  "Message",
+		Source:   "system",
+	})
+	assert.Error(t, err)
+
+	assert.NoError(t, acme.PublishNotification("client-1", "Title", "Message", notification.TypeInfo, "system"))
+	select {
+	case msg := <-ch:
+		assert.Equal(t, "test-tenants.tenant.acme.client-1", msg.Subject)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timeout waiting for notification")
+	}
+
+	globex, err := tenants.For(ctx, "globex")
+	if err != nil {
+		t.Fatalf("Failed to get tenant publisher: %v", err)
+	}
+	assert.Equal(t, "test-globex", globex.SubjectScheme().Prefix())
+
+	// Publishers of a tenant share its connection until the last one is closed
+	other, err := tenants.For(ctx, "acme")
+	assert.NoError(t, err)
+	assert.NoError(t, other.Close())
+	assert.NoError(t, other.Close())
+	assert.NoError(t, acme.PublishNotification("client-1", "Title", "Message", notification.TypeInfo, "system"))
+	assert.True(t, acme.publisher.IsConnected())
+
+	// Closing the last publisher reconnects the tenant on next use
+	assert.NoError(t, acme.Close())
+	assert.False(t, acme.publisher.IsConnected())
+	acme, err = tenants.For(ctx, "acme")
+	assert.NoError(t, err)
+	assert.NoError(t, acme.PublishNotification("client-1", "Title", "Message", notification.TypeInfo, "system"))
+}
+
+// blockingRegistry holds up lookups of one tenant until released or the context ends
+type blockingRegistry struct {
+	*memory.TenantRegistry
+	blocked string
+	release chan struct{}
+	lookups atomic.Int32
+}
+
+func (r *blockingRegistry) GetTenant(ctx context.Context, tenantID string) (*notification.Tenant, error) {
+	if tenantID == r.blocked {
+		r.lookups.Add(1)
+		select {
+		case <-r.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return r.TenantRegistry.GetTenant(ctx, tenantID)
+}
+
+func TestTenantPublishersConnectConcurrently(t *testing.T) {
+	s := startServer(t)
+
+	registry := &blockingRegistry{TenantRegistry: memory.NewTenantRegistry(), blocked: "slow", release: make(chan struct{})}
+	assert.NoError(t, registry.AddTenant(notification.Tenant{ID: "slow"}))
+	assert.NoError(t, registry.AddTenant(notification.Tenant{ID: "acme"}))
+
+	tenants, err := NewTenantPublishers(s.ClientURL(), "test-tenants", registry)
+	require.NoError(t, err)
+	defer tenants.Close()
+
+	slow := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			publisher, err := tenants.For(context.Background(), "slow")
+			if err == nil {
+				defer publisher.Close()
+			}
+			slow <- err
+		}()
+	}
+
+	// A tenant that is still connecting does not hold up the others
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	acme, err := tenants.For(ctx, "acme")
+	require.NoError(t, err)
+	defer acme.Close()
+
+	close(registry.release)
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, <-slow)
+	}
+}
+
+func TestTenantPublishersConnectCanceled(t *testing.T) {
+	s := startServer(t)
+
+	registry := &blockingRegistry{TenantRegistry: memory.NewTenantRegistry(), blocked: "slow", release: make(chan struct{})}
+	assert.NoError(t, registry.AddTenant(notification.Tenant{ID: "slow"}))
+
+	tenants, err := NewTenantPublishers(s.ClientURL(), "test-tenants", registry)
+	require.NoError(t, err)
+	defer tenants.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := tenants.For(ctx, "slow")
+		first <- err
+	}()
+	require.Eventually(t, func() bool { return registry.lookups.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+	second := make(chan error, 1)
+	go func() {
+		publisher, err := tenants.For(context.Background(), "slow")
+		if err == nil {
+			defer publisher.Close()
+		}
+		second <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	// The first caller giving up does not fail the one waiting with it
+	cancel()
+	assert.Error(t, <-first)
+	require.Eventually(t, func() bool { return registry.lookups.Load() == 2 }, time.Second, 5*time.Millisecond)
+	close(registry.release)
+	assert.NoError(t, <-second)
+
+	// Connect retries stop when the context ends
+	unreachable, err := NewTenantPublishers("nats://127.0.0.1:"+strconv.Itoa(freePort(t)), "test-tenants", registry)
+	require.NoError(t, err)
+	defer unreachable.Close()
+	timeout, cancelTimeout := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancelTimeout()
+	start := time.Now()
+	_, err = unreachable.For(timeout, "slow")
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
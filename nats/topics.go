@@ -0,0 +1,237 @@
+package nats
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/MyWeHub/notification-sdk/internal/utils"
+	"github.com/MyWeHub/notification-sdk/internal/validation"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/nats-io/nats.go"
+)
+
+// WithTopicStore sets the store PublishToTopic reads topic followers from
+func WithTopicStore(store notification.TopicStorePort) Option {
+	return func(o *publisherOptions) {
+		o.topics = store
+	}
+}
+
+// WithUserPreferences sets the preferences PublishToTopic checks for every follower
+func WithUserPreferences(preferences notification.UserPreferencesPort) Option {
+	return func(o *publisherOptions) {
+		o.preferences = preferences
+	}
+}
+
+// PublishToTopic publishes a notification to every follower of a topic. Followers who
+// muted the topic or whose preferences reject the notification are skipped; each copy
+// gets its own ID and carries the topic in its metadata.
+func (p *Publisher) PublishToTopic(ctx context.Context, topic string, notif *notification.Notification) error {
+	if err := validation.ValidateTopic(topic); err != nil {
+		return err
+	}
+	if notif == nil {
+		return notification.NewError(notification.InvalidArguments, "notification cannot be nil")
+	}
+	if p.topics == nil {
+		return notification.NewError(notification.InvalidArguments, "publisher has no topic store configured")
+	}
+
+	followers, err := p.topics.Followers(ctx, topic)
+	if err != nil {
+		return err
+	}
+
+	now := p.now()
+	copies := make([]*notification.Notification, 0, len(followers))
+	for _, follower := range followers {
+		if follower.IsMuted(now) {
+			continue
+		}
+
+		c := p.recipientCopy(notif, follower.ClientID, follower.UserID)
+		c.Metadata = make(map[string]string, len(notif.Metadata)+1)
+		for k, v := range notif.Metadata {
+			c.Metadata[k] = v
+		}
+		c.Metadata[notification.MetadataTopic] = topic
+
+		if p.preferences != nil {
+			allowed, err := p.preferences.Allows(ctx, follower.UserID, c)
+			if err != nil {
+				return err
+			}
+			if !allowed {
+				continue
+			}
+		}
+		copies = append(copies, c)
+	}
+
+	return p.fanOut(ctx, copies)
+}
+
+// TopicStore persists topic subscriptions in a JetStream Key-Value bucket
+type TopicStore struct {
+	kv nats.KeyValue
+}
+
+// NewTopicStore opens the Key-Value bucket holding topic subscriptions, creating it if needed
+func NewTopicStore(js nats.JetStreamContext, bucket string) (*TopicStore, error) {
+	kv, err := js.KeyValue(bucket)
+	if errors.Is(err, nats.ErrBucketNotFound) {
+		kv, err = js.CreateKeyValue(&nats.KeyValueConfig{Bucket: bucket, History: 1})
+	}
+	if err != nil {
+		return nil, notification.NewError(notification.Internal, "failed to open topic bucket "+bucket+": "+err.Error())
+	}
+
+	return &TopicStore{kv: kv}, nil
+}
+
+// Follow subscribes a user to a topic, replacing any existing subscription
+func (s *TopicStore) Follow(_ context.Context, subscription notification.TopicSubscription) error {
+	if err := validation.ValidateTopicSubscription(subscription); err != nil {
+		return err
+	}
+	if utils.IsZeroTime(subscription.CreatedAt) {
+		subscription.CreatedAt = utils.UTCNow()
+	}
+
+	data, err := json.Marshal(subscription)
+	if err != nil {
+		return notification.NewError(notification.Internal, "failed to marshal topic subscription: "+err.Error())
+	}
+
+	if _, err := s.kv.Put(topicKey(subscription.Topic, subscription.UserID), data); err != nil {
+		return notification.NewError(notification.Internal, "failed to store topic subscription: "+err.Error())
+	}
+	return nil
+}
+
+// Unfollow removes a user's subscription to a topic
+func (s *TopicStore) Unfollow(_ context.Context, topic, userID string) error {
+	key := topicKey(topic, userID)
+	if _, err := s.get(key, topic, userID); err != nil {
+		return err
+	}
+
+	if err := s.kv.Delete(key); err != nil {
+		return notification.NewError(notification.Internal, "failed to delete topic subscription: "+err.Error())
+	}
+	return nil
+}
+
+// Mute silences a subscription until the given time; a zero time mutes it indefinitely
+func (s *TopicStore) Mute(_ context.Context, topic, userID string, until time.Time) error {
+	return s.update(topic, userID, func(sub *notification.TopicSubscription) {
+		sub.Muted = true
+		sub.MutedUntil = until
+	})
+}
+
+// Unmute lifts the mute of a subscription
+func (s *TopicStore) Unmute(_ context.Context, topic, userID string) error {
+	return s.update(topic, userID, func(sub *notification.TopicSubscription) {
+		sub.Muted = false
+		sub.MutedUntil = time.Time{}
+	})
+}
+
+// Followers returns the subscriptions of a topic, ordered by user ID
+func (s *TopicStore) Followers(_ context.Context, topic string) ([]notification.TopicSubscription, error) {
+	result, err := s.list(encodeKeyToken(topic) + ".*")
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UserID < result[j].UserID
+	})
+	return result, nil
+}
+
+// Subscriptions returns the topics a user follows, ordered by topic
+func (s *TopicStore) Subscriptions(_ context.Context, userID string) ([]notification.TopicSubscription, error) {
+	result, err := s.list("*." + encodeKeyToken(userID))
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Topic < result[j].Topic
+	})
+	return result, nil
+}
+
+// list reads the current value of every key matching a pattern
+func (s *TopicStore) list(pattern string) ([]notification.TopicSubscription, error) {
+	watcher, err := s.kv.Watch(pattern, nats.IgnoreDeletes())
+	if err != nil {
+		return nil, notification.NewError(notification.Internal, "failed to list topic subscriptions: "+err.Error())
+	}
+	defer watcher.Stop()
+
+	var result []notification.TopicSubscription
+	// A nil entry marks the end of the values present when the watch started
+	for entry := range watcher.Updates() {
+		if entry == nil {
+			break
+		}
+		var sub notification.TopicSubscription
+		if err := json.Unmarshal(entry.Value(), &sub); err != nil {
+			return nil, notification.NewError(notification.Internal, "failed to unmarshal topic subscription: "+err.Error())
+		}
+		result = append(result, sub)
+	}
+	return result, nil
+}
+
+func (s *TopicStore) get(key, topic, userID string) (nats.KeyValueEntry, error) {
+	entry, err := s.kv.Get(key)
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return nil, notification.NewError(notification.NotFound, "user "+userID+" does not follow "+topic)
+	}
+	if err != nil {
+		return nil, notification.NewError(notification.Internal, "failed to load topic subscription: "+err.Error())
+	}
+	return entry, nil
+}
+
+// update applies a change to a subscription, guarded by the entry's revision
+func (s *TopicStore) update(topic, userID string, apply func(*notification.TopicSubscription)) error {
+	key := topicKey(topic, userID)
+	entry, err := s.get(key, topic, userID)
+	if err != nil {
+		return err
+	}
+
+	var sub notification.TopicSubscription
+	if err := json.Unmarshal(entry.Value(), &sub); err != nil {
+		return notification.NewError(notification.Internal, "failed to unmarshal topic subscription: "+err.Error())
+	}
+	apply(&sub)
+
+	data, err := json.Marshal(sub)
+	if err != nil {
+		return notification.NewError(notification.Internal, "failed to marshal topic subscription: "+err.Error())
+	}
+	if _, err := s.kv.Update(key, data, entry.Revision()); err != nil {
+		return notification.NewError(notification.Internal, "failed to update topic subscription: "+err.Error())
+	}
+	return nil
+}
+
+// topicKey builds the Key-Value key of a subscription. Topics and user IDs may contain
+// characters that are not allowed in keys, so both are base64url encoded.
+func topicKey(topic, userID string) string {
+	return encodeKeyToken(topic) + "." + encodeKeyToken(userID)
+}
+
+func encodeKeyToken(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
@@ -0,0 +1,155 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/MyWeHub/notification-sdk/memory"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+type denySources map[string]bool
+
+func (d denySources) Allows(_ context.Context, _ string, n *notification.Notification) (bool, error) {
+	return !d[n.Source], nil
+}
+
+func TestKVTopicStore(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	js, err := nc.JetStream()
+	if err != nil {
+		t.Fatalf("Failed to create JetStream context: %v", err)
+	}
+	_ = js.DeleteKeyValue("test-topics")
+	defer js.DeleteKeyValue("test-topics")
+
+	store, err := NewTopicStore(js, "test-topics")
+	if err != nil {
+		t.Fatalf("Failed to create topic store: %v", err)
+	}
+	ctx := context.Background()
+
+	assert.NoError(t, store.Follow(ctx, notification.TopicSubscription{Topic: "project:123", UserID: "user.2@example.com", ClientID: "client-2"}))
+	assert.NoError(t, store.Follow(ctx, notification.TopicSubscription{Topic: "project:123", UserID: "u-1", ClientID: "client-1"}))
+	assert.NoError(t, store.Follow(ctx, notification.TopicSubscription{Topic: "project:1234", UserID: "u-1", ClientID: "client-1"}))
+
+	followers, err := store.Followers(ctx, "project:123")
+	assert.NoError(t, err)
+	assert.Len(t, followers, 2)
+	assert.Equal(t, "u-1", followers[0].UserID)
+	assert.Equal(t, "user.2@example.com", followers[1].UserID)
+
+	subscriptions, err := store.Subscriptions(ctx, "u-1")
+	assert.NoError(t, err)
+	assert.Len(t, subscriptions, 2)
+
+	assert.NoError(t, store.Mute(ctx, "project:123", "u-1", time.Time{}))
+	followers, _ = store.Followers(ctx, "project:123")
+	assert.True(t, followers[0].IsMuted(time.Now()))
+	assert.NoError(t, store.Unmute(ctx, "project:123", "u-1"))
+
+	assert.NoError(t, store.Unfollow(ctx, "project:123", "u-1"))
+	assert.Error(t, store.Unfollow(ctx, "project:123", "u-1"))
+	followers, _ = store.Followers(ctx, "project:123")
+	assert.Len(t, followers, 1)
+
+	followers, err = store.Followers(ctx, "project:unknown")
+	assert.NoError(t, err)
+	assert.Empty(t, followers)
+}
+
+func TestPublishToTopic(t *testing.T) {
+	nc, err := nats.Connect(nats.DefaultURL, nats.Timeout(500*time.Millisecond))
+	if err != nil {
+		t.Skip("Skipping test as no NATS server is available")
+	}
+	defer nc.Close()
+
+	publisher, err := NewPublisher(nats.DefaultURL, "test-topics")
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer publisher.Close()
+
+	ctx := context.Background()
+	notif := &notification.Notification{Title: "Build failed", Message: "main is red", Source: "ci", Type: notification.TypeError}
+
+	err = publisher.PublishToTopic(ctx, "project:123", notif)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no topic store configured")
+
+	store := memory.NewTopicStore()
+	for _, sub := range []notification.TopicSubscription{
+		{Topic: "project:123", UserID: "u-1", ClientID: "client-1"},
+		{Topic: "project:123", UserID: "u-2", ClientID: "client-2", Muted: true},
+		{Topic: "project:123", UserID: "u-3", ClientID: "client-3", Muted: true, MutedUntil: time.Now().Add(-time.Minute)},
+		{Topic: "project:123", UserID: "u-4", ClientID: "client-4"},
+	} {
+		assert.NoError(t, store.Follow(ctx, sub))
+	}
+	filtered, err := New(
+		WithURL(nats.DefaultURL),
+		WithSubjectPrefix("test-topics"),
+		WithTopicStore(store),
+		WithUserPreferences(denySources{"ci": true}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer filtered.Close()
+	followed, err := New(WithURL(nats.DefaultURL), WithSubjectPrefix("test-topics"), WithTopicStore(store))
+	if err != nil {
+		t.Fatalf("Failed to create notification publisher: %v", err)
+	}
+	defer followed.Close()
+
+	var mu sync.Mutex
+	received := make(map[string]*notification.Notification)
+	subscription, err := nc.Subscribe("test-topics.*", func(msg *nats.Msg) {
+		var n notification.Notification
+		if err := json.Unmarshal(msg.Data, &n); err != nil {
+			t.Errorf("Failed to unmarshal notification: %v", err)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		received[n.UserID] = &n
+	})
+	if err != nil {
+		t.Fatalf("Failed to subscribe to NATS: %v", err)
+	}
+	defer subscription.Unsubscribe()
+	if err := nc.Flush(); err != nil {
+		t.Fatalf("Failed to flush connection: %v", err)
+	}
+
+	// Preferences reject everything from ci
+	assert.NoError(t, filtered.PublishToTopic(ctx, "project:123", notif))
+	assert.NoError(t, followed.PublishToTopic(ctx, "project:123", notif))
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 3
+	}, 3*time.Second, 20*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Contains(t, received, "u-1")
+	assert.Contains(t, received, "u-3")
+	assert.Contains(t, received, "u-4")
+	assert.NotContains(t, received, "u-2")
+	assert.Equal(t, "client-1", received["u-1"].ClientID)
+	assert.Equal(t, "project:123", received["u-1"].Metadata[notification.MetadataTopic])
+	assert.Nil(t, notif.Metadata, "the caller's notification must not be modified")
+}
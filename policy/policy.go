@@ -0,0 +1,223 @@
+// Package policy authorizes notifications against declarative publishing rules.
+package policy
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule allows the sources it matches to publish some notification types to some tenants
+type Rule struct {
+	// Sources are path.Match patterns, e.g. "billing-service" or "ci-*"; "*" matches every source
+	Sources []string `json:"sources" yaml:"sources"`
+	// Types are notification type names such as "info"; empty allows every type
+	Types []string `json:"types,omitempty" yaml:"types,omitempty"`
+	// Tenants the recipients must belong to; empty allows every recipient unless OwnTenant is set
+	Tenants []string `json:"tenants,omitempty" yaml:"tenants,omitempty"`
+	// OwnTenant allows recipients of the publishing principal's own tenant
+	OwnTenant bool `json:"own_tenant,omitempty" yaml:"own_tenant,omitempty"`
+}
+
+// Policy is a set of allow rules. A notification may be published when at least one rule
+// matching the publisher's principal allows its type and recipient; everything else is denied.
+type Policy struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// Parse reads a policy from JSON
+func Parse(data []byte) (*Policy, error) {
+	var p Policy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, notification.NewError(notification.InvalidArguments, "failed to parse policy: "+err.Error())
+	}
+	return &p, nil
+}
+
+// ParseYAML reads a policy from YAML
+func ParseYAML(data []byte) (*Policy, error) {
+	var p Policy
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, notification.NewError(notification.InvalidArguments, "failed to parse policy: "+err.Error())
+	}
+	return &p, nil
+}
+
+// Load reads a policy file, parsing .yaml and .yml files as YAML and anything else as JSON
+func Load(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, notification.NewError(notification.InvalidArguments, "failed to read policy: "+err.Error())
+	}
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		return ParseYAML(data)
+	default:
+		return Parse(data)
+	}
+}
+
+type compiledRule struct {
+	sources   []string
+	types     map[notification.NotificationType]bool
+	tenants   []string
+	ownTenant bool
+}
+
+// Engine evaluates a policy. It implements notification.AuthorizerPort.
+type Engine struct {
+	rules    []compiledRule
+	registry notification.TenantRegistryPort
+}
+
+var _ notification.AuthorizerPort = (*Engine)(nil)
+
+// New validates a policy and creates its engine. The registry decides which tenant a
+// client belongs to and is only required when rules restrict tenants.
+//
+// The engine authorizes the principal found in the context with
+// notification.PrincipalFromContext, not the notification's Source: notifications
+// without a principal, or whose Source differs from it, are denied.
+func New(p *Policy, registry notification.TenantRegistryPort) (*Engine, error) {
+	if p == nil {
+		return nil, notification.NewError(notification.InvalidArguments, "policy cannot be nil")
+	}
+
+	e := &Engine{registry: registry}
+	for i, rule := range p.Rules {
+		compiled, err := compile(rule)
+		if err != nil {
+			return nil, notification.NewError(notification.InvalidArguments, "policy rule "+strconv.Itoa(i+1)+": "+err.Error())
+		}
+		if compiled.restrictsTenants() && registry == nil {
+			return nil, notification.NewError(notification.InvalidArguments, "policy rule "+strconv.Itoa(i+1)+" restricts tenants but no tenant registry was given")
+		}
+		e.rules = append(e.rules, compiled)
+	}
+	return e, nil
+}
+
+func compile(rule Rule) (compiledRule, error) {
+	var c compiledRule
+	if len(rule.Sources) == 0 {
+		return c, notification.NewError(notification.InvalidArguments, "sources cannot be empty")
+	}
+	for _, pattern := range rule.Sources {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return c, notification.NewError(notification.InvalidArguments, "invalid source pattern "+pattern)
+		}
+	}
+	c.sources = rule.Sources
+
+	if len(rule.Types) > 0 {
+		c.types = make(map[notification.NotificationType]bool, len(rule.Types))
+		for _, name := range rule.Types {
+			t, err := notification.ParseNotificationType(name)
+			if err != nil {
+				return c, err
+			}
+			c.types[t] = true
+		}
+	}
+
+	c.tenants = rule.Tenants
+	c.ownTenant = rule.OwnTenant
+	return c, nil
+}
+
+func (r compiledRule) restrictsTenants() bool {
+	return len(r.tenants) > 0 || r.ownTenant
+}
+
+// Authorize returns nil when a rule allows the notification and a PermissionDenied error otherwise
+func (e *Engine) Authorize(ctx context.Context, n *notification.Notification) error {
+	if n == nil {
+		return notification.NewError(notification.InvalidArguments, "notification cannot be nil")
+	}
+
+	principal, ok := notification.PrincipalFromContext(ctx)
+	if !ok || principal.Source == "" {
+		return notification.NewError(notification.PermissionDenied, "no principal to authorize the notification for")
+	}
+	if n.Source != principal.Source {
+		return notification.NewError(notification.PermissionDenied, "publisher "+principal.Source+" may not publish as source "+n.Source)
+	}
+
+	matched := false
+	for _, rule := range e.rules {
+		if !rule.matchesSource(principal.Source) {
+			continue
+		}
+		matched = true
+
+		if rule.types != nil && !rule.types[n.Type] {
+			continue
+		}
+		allowed, err := e.allowsRecipient(ctx, rule, principal, n)
+		if err != nil {
+			return err
+		}
+		if allowed {
+			return nil
+		}
+	}
+
+	if !matched {
+		return notification.NewError(notification.PermissionDenied, "no policy rule allows source "+n.Source+" to publish")
+	}
+	return notification.NewError(notification.PermissionDenied, "source "+n.Source+" may not publish "+n.Type.String()+" notifications to "+recipient(ctx, n))
+}
+
+func (r compiledRule) matchesSource(source string) bool {
+	for _, pattern := range r.sources {
+		if ok, _ := path.Match(pattern, source); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsRecipient checks the tenant restriction of a rule. Organization broadcasts, which
+// only the publisher marks in ctx, are addressed to the organization itself; everything
+// else is checked against the client it is published to, whatever its Audience says.
+func (e *Engine) allowsRecipient(ctx context.Context, rule compiledRule, principal notification.Principal, n *notification.Notification) (bool, error) {
+	if !rule.restrictsTenants() {
+		return true, nil
+	}
+
+	tenants := rule.tenants
+	if rule.ownTenant && principal.TenantID != "" {
+		tenants = append(slices.Clip(tenants), principal.TenantID)
+	}
+
+	if orgID, ok := notification.OrgBroadcastFromContext(ctx); ok {
+		return slices.Contains(tenants, orgID), nil
+	}
+
+	for _, tenantID := range tenants {
+		owns, err := e.registry.OwnsClient(ctx, tenantID, n.ClientID)
+		if err != nil {
+			return false, err
+		}
+		if owns {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func recipient(ctx context.Context, n *notification.Notification) string {
+	if orgID, ok := notification.OrgBroadcastFromContext(ctx); ok {
+		return "organization " + orgID
+	}
+	return "client " + n.ClientID
+}
@@ -0,0 +1,132 @@
+package policy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/MyWeHub/notification-sdk/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+const billingPolicy = `{
+  "rules": [
+    {"sources": ["billing-service"], "types": ["info", "warning"], "tenants": ["acme"]},
+    {"sources": ["support-*"], "own_tenant": true},
+    {"sources": ["ops-*"]}
+  ]
+}`
+
+func newRegistry(t *testing.T) *memory.TenantRegistry {
+	registry := memory.NewTenantRegistry()
+	assert.NoError(t, registry.AddTenant(notification.Tenant{ID: "acme"}))
+	assert.NoError(t, registry.AddTenant(notification.Tenant{ID: "globex"}))
+	assert.NoError(t, registry.AddClient("acme", "acme-client"))
+	assert.NoError(t, registry.AddClient("globex", "globex-client"))
+	return registry
+}
+
+func TestEngineAuthorize(t *testing.T) {
+	p, err := Parse([]byte(billingPolicy))
+	assert.NoError(t, err)
+	engine, err := New(p, newRegistry(t))
+	assert.NoError(t, err)
+
+	billing := notification.Principal{Source: "billing-service"}
+	support := notification.Principal{Source: "support-desk", TenantID: "globex"}
+	tests := []struct {
+		name      string
+		principal *notification.Principal
+		broadcast string
+		notif     *notification.Notification
+		allowed   bool
+	}{
+		{"own tenant info", &billing, "", &notification.Notification{Source: "billing-service", ClientID: "acme-client", Type: notification.TypeInfo}, true},
+		{"own tenant warning", &billing, "", &notification.Notification{Source: "billing-service", ClientID: "acme-client", Type: notification.TypeWarning}, true},
+		{"own tenant error", &billing, "", &notification.Notification{Source: "billing-service", ClientID: "acme-client", Type: notification.TypeError}, false},
+		{"other tenant", &billing, "", &notification.Notification{Source: "billing-service", ClientID: "globex-client", Type: notification.TypeInfo}, false},
+		{"own organization broadcast", &billing, "acme", &notification.Notification{Source: "billing-service", ClientID: "acme"}, true},
+		{"other organization broadcast", &billing, "globex", &notification.Notification{Source: "billing-service", ClientID: "globex"}, false},
+		{"unrestricted pattern", &notification.Principal{Source: "ops-alerts"}, "", &notification.Notification{Source: "ops-alerts", ClientID: "globex-client", Type: notification.TypeError}, true},
+		{"unknown source", &notification.Principal{Source: "marketing"}, "", &notification.Notification{Source: "marketing", ClientID: "acme-client", Type: notification.TypeInfo}, false},
+		{"claimed source", &billing, "", &notification.Notification{Source: "ops-alerts", ClientID: "globex-client", Type: notification.TypeError}, false},
+		{"no principal", nil, "", &notification.Notification{Source: "ops-alerts", ClientID: "globex-client", Type: notification.TypeError}, false},
+		{"principal's tenant", &support, "", &notification.Notification{Source: "support-desk", ClientID: "globex-client", Type: notification.TypeError}, true},
+		{"outside principal's tenant", &support, "", &notification.Notification{Source: "support-desk", ClientID: "acme-client", Type: notification.TypeInfo}, false},
+		{"principal's organization broadcast", &support, "globex", &notification.Notification{Source: "support-desk", ClientID: "globex"}, true},
+		{"forged organization audience", &billing, "", &notification.Notification{Source: "billing-service", ClientID: "globex-client", Type: notification.TypeInfo, Audience: &notification.Audience{Kind: notification.AudienceOrganization, OrgID: "acme"}}, false},
+		{"forged audience of own tenant", &support, "", &notification.Notification{Source: "support-desk", ClientID: "acme-client", Type: notification.TypeInfo, Audience: &notification.Audience{Kind: notification.AudienceOrganization, OrgID: "globex"}}, false},
+		{"principal without tenant", &notification.Principal{Source: "support-bot"}, "", &notification.Notification{Source: "support-bot", ClientID: "acme-client", Type: notification.TypeInfo}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = notification.ContextWithPrincipal(ctx, *tt.principal)
+			}
+			if tt.broadcast != "" {
+				ctx = notification.ContextWithOrgBroadcast(ctx, tt.broadcast)
+			}
+			err := engine.Authorize(ctx, tt.notif)
+			if tt.allowed {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.EqualValues(t, notification.PermissionDenied, err.(*notification.Error).Code)
+			}
+		})
+	}
+}
+
+func TestNewInvalidPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   *Policy
+		registry notification.TenantRegistryPort
+	}{
+		{"nil policy", nil, nil},
+		{"no sources", &Policy{Rules: []Rule{{Types: []string{"info"}}}}, nil},
+		{"bad pattern", &Policy{Rules: []Rule{{Sources: []string{"[billing"}}}}, nil},
+		{"unknown type", &Policy{Rules: []Rule{{Sources: []string{"*"}, Types: []string{"urgent"}}}}, nil},
+		{"tenants without registry", &Policy{Rules: []Rule{{Sources: []string{"*"}, Tenants: []string{"acme"}}}}, nil},
+		{"own tenant without registry", &Policy{Rules: []Rule{{Sources: []string{"*"}, OwnTenant: true}}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.policy, tt.registry)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	yamlFile := filepath.Join(dir, "policy.yaml")
+	assert.NoError(t, os.WriteFile(yamlFile, []byte(`
+rules:
+  - sources: [billing-service]
+    types: [info, warning]
+    tenants: [acme]
+`), 0o600))
+	p, err := Load(yamlFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []Rule{{Sources: []string{"billing-service"}, Types: []string{"info", "warning"}, Tenants: []string{"acme"}}}, p.Rules)
+
+	jsonFile := filepath.Join(dir, "policy.json")
+	assert.NoError(t, os.WriteFile(jsonFile, []byte(billingPolicy), 0o600))
+	p, err = Load(jsonFile)
+	assert.NoError(t, err)
+	assert.Len(t, p.Rules, 3)
+	assert.True(t, p.Rules[1].OwnTenant)
+
+	_, err = Load(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+	_, err = Parse([]byte("{"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package templates
+
+import (
+	"strings"
+)
+
+// DefaultLocale is the last entry of every fallback chain unless a registry overrides it
+const DefaultLocale = "en"
+
+// NormalizeLocale canonicalizes a locale tag: "fr_ca" and "FR-ca" both become "fr-CA"
+func NormalizeLocale(locale string) string {
+	parts := strings.Split(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"), "-")
+	for i, part := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(part)
+		case len(part) == 2:
+			parts[i] = strings.ToUpper(part)
+		case len(part) == 4:
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		default:
+			parts[i] = strings.ToLower(part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// FallbackChain lists the locales to try for locale, most specific first, ending in
+// defaultLocale: "fr-CA" gives ["fr-CA", "fr", "en"]
+func FallbackChain(locale, defaultLocale string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	add := func(l string) {
+		if l != "" && !seen[l] {
+			seen[l] = true
+			chain = append(chain, l)
+		}
+	}
+
+	tag := NormalizeLocale(locale)
+	for tag != "" {
+		add(tag)
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	add(NormalizeLocale(defaultLocale))
+
+	return chain
+}
+
+// language returns the language subtag of a normalized locale
+func language(locale string) string {
+	if i := strings.Index(locale, "-"); i >= 0 {
+		return locale[:i]
+	}
+	return locale
+}
@@ -0,0 +1,65 @@
+package templates
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	assert.Equal(t, "fr-CA", NormalizeLocale("fr_ca"))
+	assert.Equal(t, "fr-CA", NormalizeLocale("FR-CA"))
+	assert.Equal(t, "zh-Hant-TW", NormalizeLocale("zh_hant_tw"))
+	assert.Equal(t, "en", NormalizeLocale(" en "))
+	assert.Equal(t, "", NormalizeLocale(""))
+}
+
+func TestFallbackChain(t *testing.T) {
+	assert.Equal(t, []string{"fr-CA", "fr", "en"}, FallbackChain("fr-CA", "en"))
+	assert.Equal(t, []string{"zh-Hant-TW", "zh-Hant", "zh", "en"}, FallbackChain("zh_Hant_TW", "en"))
+	assert.Equal(t, []string{"en-GB", "en"}, FallbackChain("en-GB", "en"))
+	assert.Equal(t, []string{"de"}, FallbackChain("", "de"))
+}
+
+func TestPluralCategory(t *testing.T) {
+	tests := []struct {
+		locale   string
+		n        int
+		expected string
+	}{
+		{"en", 1, PluralOne},
+		{"en", 0, PluralOther},
+		{"en-US", 2, PluralOther},
+		{"fr-CA", 0, PluralOne},
+		{"fr", 2, PluralOther},
+		{"ru", 21, PluralOne},
+		{"ru", 3, PluralFew},
+		{"ru", 11, PluralMany},
+		{"pl", 22, PluralFew},
+		{"pl", 5, PluralMany},
+		{"ja", 1, PluralOther},
+		{"xx", 1, PluralOne},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, PluralCategory(tt.locale, tt.n), "%s %d", tt.locale, tt.n)
+	}
+}
+
+func TestRegisterPluralRuleConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			RegisterPluralRule("qq", other)
+		}()
+		go func() {
+			defer wg.Done()
+			_ = PluralCategory("qq", 1)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, PluralOther, PluralCategory("qq", 1))
+}
@@ -0,0 +1,150 @@
+package templates
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Plural categories, following the CLDR names
+const (
+	PluralZero  = "zero"
+	PluralOne   = "one"
+	PluralTwo   = "two"
+	PluralFew   = "few"
+	PluralMany  = "many"
+	PluralOther = "other"
+)
+
+// PluralRule maps a count to its plural category
+type PluralRule func(n int) string
+
+// pluralRules holds the cardinal rules of the supported languages; languages without a
+// rule use the English one. pluralMu guards it against RegisterPluralRule.
+var pluralMu sync.RWMutex
+
+var pluralRules = map[string]PluralRule{
+	"en": oneOther,
+	"de": oneOther,
+	"nl": oneOther,
+	"es": oneOther,
+	"it": oneOther,
+	"fr": func(n int) string {
+		if n == 0 || n == 1 {
+			return PluralOne
+		}
+		return PluralOther
+	},
+	"pt": func(n int) string {
+		if n == 0 || n == 1 {
+			return PluralOne
+		}
+		return PluralOther
+	},
+	"ru": slavic,
+	"uk": slavic,
+	"pl": func(n int) string {
+		switch {
+		case n == 1:
+			return PluralOne
+		case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+			return PluralFew
+		default:
+			return PluralMany
+		}
+	},
+	"ar": func(n int) string {
+		switch {
+		case n == 0:
+			return PluralZero
+		case n == 1:
+			return PluralOne
+		case n == 2:
+			return PluralTwo
+		case n%100 >= 3 && n%100 <= 10:
+			return PluralFew
+		case n%100 >= 11:
+			return PluralMany
+		default:
+			return PluralOther
+		}
+	},
+	"ja": other,
+	"zh": other,
+	"ko": other,
+	"tr": oneOther,
+}
+
+func oneOther(n int) string {
+	if n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+func other(int) string {
+	return PluralOther
+}
+
+func slavic(n int) string {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return PluralOne
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return PluralFew
+	default:
+		return PluralMany
+	}
+}
+
+// RegisterPluralRule sets the plural rule of a language, replacing any built-in rule.
+// It must be called before templates of that language are registered.
+func RegisterPluralRule(lang string, rule PluralRule) {
+	pluralMu.Lock()
+	defer pluralMu.Unlock()
+	pluralRules[strings.ToLower(lang)] = rule
+}
+
+// PluralCategory returns the plural category of n in locale
+func PluralCategory(locale string, n int) string {
+	return category(ruleFor(locale), n)
+}
+
+func ruleFor(locale string) PluralRule {
+	pluralMu.RLock()
+	defer pluralMu.RUnlock()
+	if rule, ok := pluralRules[language(NormalizeLocale(locale))]; ok {
+		return rule
+	}
+	return oneOther
+}
+
+func category(rule PluralRule, n int) string {
+	if n < 0 {
+		n = -n
+	}
+	return rule(n)
+}
+
+// templateFuncs returns the functions available to templates of a locale. plural takes a
+// count followed by category/text pairs and replaces # in the chosen text with the count:
+//
+//	{{plural .Count "one" "# file" "other" "# files"}}
+func templateFuncs(locale string) map[string]any {
+	rule := ruleFor(locale)
+	return map[string]any{
+		"plural": func(n int, forms ...string) string {
+			category := category(rule, n)
+			var fallback string
+			for i := 0; i+1 < len(forms); i += 2 {
+				if forms[i] == category {
+					return strings.ReplaceAll(forms[i+1], "#", strconv.Itoa(n))
+				}
+				if forms[i] == PluralOther {
+					fallback = forms[i+1]
+				}
+			}
+			return strings.ReplaceAll(fallback, "#", strconv.Itoa(n))
+		},
+	}
+}
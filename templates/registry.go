@@ -0,0 +1,314 @@
+// Package templates renders notification content from versioned Go templates.
+package templates
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"strconv"
+	"sync"
+	texttemplate "text/template"
+
+	"github.com/MyWeHub/notification-sdk/internal/validation"
+
+	notification "github.com/MyWeHub/notification-sdk"
+)
+
+// Template defines the content of a notification as text/template sources
+type Template struct {
+	ID      string
+	Version int
+	// Locale is a BCP 47 tag such as "fr-CA"; empty means the registry's default locale
+	Locale  string
+	Type    notification.NotificationType
+	Source  string
+	Title   string
+	Message string
+	// Variants holds channel-specific content; email variants with HTML set are
+	// rendered with html/template
+	Variants map[notification.Channel]Variant
+}
+
+// Variant is the template source of a notification for a single channel
+type Variant struct {
+	Title string
+	Body  string
+	HTML  bool
+}
+
+// executor is satisfied by both text/template and html/template templates
+type executor interface {
+	Execute(wr *bytes.Buffer, data any) error
+}
+
+type textExecutor struct{ t *texttemplate.Template }
+
+func (e textExecutor) Execute(wr *bytes.Buffer, data any) error { return e.t.Execute(wr, data) }
+
+type htmlExecutor struct{ t *htmltemplate.Template }
+
+func (e htmlExecutor) Execute(wr *bytes.Buffer, data any) error { return e.t.Execute(wr, data) }
+
+type compiledVariant struct {
+	title executor
+	body  executor
+	html  bool
+}
+
+type compiledTemplate struct {
+	def      Template
+	title    executor
+	message  executor
+	variants map[notification.Channel]compiledVariant
+}
+
+// Registry stores compiled templates keyed by ID, version and locale
+type Registry struct {
+	mu            sync.RWMutex
+	defaultLocale string
+	templates     map[string]map[string]map[int]*compiledTemplate
+	latest        map[string]map[string]int
+}
+
+// NewRegistry creates an empty template registry falling back to DefaultLocale
+func NewRegistry() *Registry {
+	return NewRegistryWithLocale(DefaultLocale)
+}
+
+// NewRegistryWithLocale creates an empty template registry whose locale fallback chains
+// end in defaultLocale
+func NewRegistryWithLocale(defaultLocale string) *Registry {
+	return &Registry{
+		defaultLocale: NormalizeLocale(defaultLocale),
+		templates:     make(map[string]map[string]map[int]*compiledTemplate),
+		latest:        make(map[string]map[string]int),
+	}
+}
+
+// Register compiles and stores a template. Templates without a locale are registered
+// under the registry's default locale. Registering an existing ID, version and locale fails.
+func (r *Registry) Register(t Template) error {
+	if err := validation.ValidateTemplateID(t.ID); err != nil {
+		return err
+	}
+	if t.Version < 1 {
+		return notification.NewError(notification.InvalidArguments, "template version must be positive")
+	}
+	t.Locale = NormalizeLocale(t.Locale)
+	if t.Locale == "" {
+		t.Locale = r.defaultLocale
+	}
+
+	compiled, err := compile(t)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	locales, ok := r.templates[t.ID]
+	if !ok {
+		locales = make(map[string]map[int]*compiledTemplate)
+		r.templates[t.ID] = locales
+		r.latest[t.ID] = make(map[string]int)
+	}
+	versions, ok := locales[t.Locale]
+	if !ok {
+		versions = make(map[int]*compiledTemplate)
+		locales[t.Locale] = versions
+	}
+	if _, exists := versions[t.Version]; exists {
+		return notification.NewError(notification.AlreadyExists, "template "+templateKey(t.ID, t.Version, t.Locale)+" already registered")
+	}
+
+	versions[t.Version] = compiled
+	if t.Version > r.latest[t.ID][t.Locale] {
+		r.latest[t.ID][t.Locale] = t.Version
+	}
+	return nil
+}
+
+// Render renders the latest version of a template in the default locale
+func (r *Registry) Render(templateID string, data any) (*notification.RenderedContent, error) {
+	return r.RenderLocale(templateID, r.defaultLocale, data)
+}
+
+// RenderLocale renders a template for a locale, walking the locale's fallback chain
+// (fr-CA, fr, default) and using the latest version registered for the first match
+func (r *Registry) RenderLocale(templateID, locale string, data any) (*notification.RenderedContent, error) {
+	r.mu.RLock()
+	var compiled *compiledTemplate
+	for _, candidate := range FallbackChain(locale, r.defaultLocale) {
+		if version, ok := r.latest[templateID][candidate]; ok {
+			compiled = r.templates[templateID][candidate][version]
+			break
+		}
+	}
+	r.mu.RUnlock()
+	if compiled == nil {
+		return nil, notification.NewError(notification.NotFound, "template "+templateID+" not found for locale "+locale)
+	}
+
+	return renderAndValidate(compiled, data)
+}
+
+// RenderVersion renders a specific version of a template in the default locale
+func (r *Registry) RenderVersion(templateID string, version int, data any) (*notification.RenderedContent, error) {
+	return r.RenderVersionLocale(templateID, version, r.defaultLocale, data)
+}
+
+// RenderVersionLocale renders a specific version of a template, walking the locale's
+// fallback chain within that version
+func (r *Registry) RenderVersionLocale(templateID string, version int, locale string, data any) (*notification.RenderedContent, error) {
+	r.mu.RLock()
+	var compiled *compiledTemplate
+	for _, candidate := range FallbackChain(locale, r.defaultLocale) {
+		if c, ok := r.templates[templateID][candidate][version]; ok {
+			compiled = c
+			break
+		}
+	}
+	r.mu.RUnlock()
+	if compiled == nil {
+		return nil, notification.NewError(notification.NotFound, "template "+templateKey(templateID, version, locale)+" not found")
+	}
+
+	return renderAndValidate(compiled, data)
+}
+
+func renderAndValidate(compiled *compiledTemplate, data any) (*notification.RenderedContent, error) {
+	content, err := compiled.render(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validation.ValidateRenderedContent(content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+func (c *compiledTemplate) render(data any) (*notification.RenderedContent, error) {
+	key := templateKey(c.def.ID, c.def.Version, c.def.Locale)
+
+	title, err := execute(c.title, data, key)
+	if err != nil {
+		return nil, err
+	}
+	message, err := execute(c.message, data, key)
+	if err != nil {
+		return nil, err
+	}
+
+	content := &notification.RenderedContent{
+		TemplateID: c.def.ID,
+		Version:    c.def.Version,
+		Locale:     c.def.Locale,
+		Type:       c.def.Type,
+		Source:     c.def.Source,
+		Title:      title,
+		Message:    message,
+	}
+
+	if len(c.variants) > 0 {
+		content.Variants = make(map[notification.Channel]notification.ChannelContent, len(c.variants))
+		for channel, variant := range c.variants {
+			variantKey := key + "/" + string(channel)
+
+			var title string
+			if variant.title != nil {
+				if title, err = execute(variant.title, data, variantKey); err != nil {
+					return nil, err
+				}
+			}
+			body, err := execute(variant.body, data, variantKey)
+			if err != nil {
+				return nil, err
+			}
+
+			content.Variants[channel] = notification.ChannelContent{
+				Title: title,
+				Body:  body,
+				HTML:  variant.html,
+			}
+		}
+	}
+
+	return content, nil
+}
+
+// compile parses every source of a template
+func compile(t Template) (*compiledTemplate, error) {
+	key := templateKey(t.ID, t.Version, t.Locale)
+	funcs := templateFuncs(t.Locale)
+
+	title, err := parseText(key+"/title", t.Title, funcs)
+	if err != nil {
+		return nil, err
+	}
+	message, err := parseText(key+"/message", t.Message, funcs)
+	if err != nil {
+		return nil, err
+	}
+
+	compiled := &compiledTemplate{
+		def:      t,
+		title:    title,
+		message:  message,
+		variants: make(map[notification.Channel]compiledVariant, len(t.Variants)),
+	}
+
+	for channel, variant := range t.Variants {
+		if variant.HTML && channel != notification.ChannelEmail {
+			return nil, notification.NewError(notification.InvalidArguments, "template "+key+": html content is only supported for the email channel")
+		}
+
+		name := key + "/" + string(channel)
+		var cv compiledVariant
+		if variant.Title != "" {
+			if cv.title, err = parseText(name+"/title", variant.Title, funcs); err != nil {
+				return nil, err
+			}
+		}
+		if variant.HTML {
+			cv.body, err = parseHTML(name+"/body", variant.Body, funcs)
+		} else {
+			cv.body, err = parseText(name+"/body", variant.Body, funcs)
+		}
+		if err != nil {
+			return nil, err
+		}
+		cv.html = variant.HTML
+		compiled.variants[channel] = cv
+	}
+
+	return compiled, nil
+}
+
+func parseText(name, source string, funcs map[string]any) (executor, error) {
+	t, err := texttemplate.New(name).Option("missingkey=error").Funcs(funcs).Parse(source)
+	if err != nil {
+		return nil, notification.NewError(notification.InvalidArguments, "failed to parse template "+name+": "+err.Error())
+	}
+	return textExecutor{t}, nil
+}
+
+func parseHTML(name, source string, funcs map[string]any) (executor, error) {
+	t, err := htmltemplate.New(name).Option("missingkey=error").Funcs(funcs).Parse(source)
+	if err != nil {
+		return nil, notification.NewError(notification.InvalidArguments, "failed to parse template "+name+": "+err.Error())
+	}
+	return htmlExecutor{t}, nil
+}
+
+func execute(e executor, data any, key string) (string, error) {
+	var buf bytes.Buffer
+	if err := e.Execute(&buf, data); err != nil {
+		return "", notification.NewError(notification.InvalidArguments, "failed to render template "+key+": "+err.Error())
+	}
+	return buf.String(), nil
+}
+
+func templateKey(id string, version int, locale string) string {
+	return id + "@v" + strconv.Itoa(version) + "/" + locale
+}
@@ -0,0 +1,169 @@
+package templates
+
+import (
+	"strings"
+	"testing"
+
+	notification "github.com/MyWeHub/notification-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func orderShipped(version int) Template {
+	return Template{
+		ID:      "order-shipped",
+		Version: version,
+		Type:    notification.TypeSuccess,
+		Source:  "order-service",
+		Title:   "Order {{.OrderID}} shipped",
+		Message: "Your order {{.OrderID}} is on its way",
+		Variants: map[notification.Channel]Variant{
+			notification.ChannelEmail: {Title: "Your order {{.OrderID}}", Body: "<p>Hello {{.Name}}</p>", HTML: true},
+			notification.ChannelSMS:   {Body: "Order {{.OrderID}} shipped"},
+		},
+	}
+}
+
+func TestRegistryRender(t *testing.T) {
+	registry := NewRegistry()
+	assert.NoError(t, registry.Register(orderShipped(1)))
+
+	content, err := registry.Render("order-shipped", map[string]string{"OrderID": "A-1", "Name": "<Ann>"})
+	assert.NoError(t, err)
+	assert.Equal(t, "order-shipped", content.TemplateID)
+	assert.Equal(t, 1, content.Version)
+	assert.Equal(t, notification.TypeSuccess, content.Type)
+	assert.Equal(t, "order-service", content.Source)
+	assert.Equal(t, "Order A-1 shipped", content.Title)
+	assert.Equal(t, "Your order A-1 is on its way", content.Message)
+
+	email := content.Variants[notification.ChannelEmail]
+	assert.Equal(t, "Your order A-1", email.Title)
+	assert.Equal(t, "<p>Hello &lt;Ann&gt;</p>", email.Body)
+	assert.True(t, email.HTML)
+	assert.Equal(t, "Order A-1 shipped", content.Variants[notification.ChannelSMS].Body)
+}
+
+func TestRegistryVersions(t *testing.T) {
+	registry := NewRegistry()
+	v2 := orderShipped(2)
+	v2.Title = "Shipped: {{.OrderID}}"
+	assert.NoError(t, registry.Register(v2))
+	assert.NoError(t, registry.Register(orderShipped(1)))
+
+	data := map[string]string{"OrderID": "A-1", "Name": "Ann"}
+
+	latest, err := registry.Render("order-shipped", data)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, latest.Version)
+	assert.Equal(t, "Shipped: A-1", latest.Title)
+
+	first, err := registry.RenderVersion("order-shipped", 1, data)
+	assert.NoError(t, err)
+	assert.Equal(t, "Order A-1 shipped", first.Title)
+
+	err = registry.Register(orderShipped(1))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "already registered")
+}
+
+func TestRegistryErrors(t *testing.T) {
+	registry := NewRegistry()
+
+	tests := []struct {
+		name     string
+		template Template
+		contains string
+	}{
+		{"missing ID", Template{Version: 1, Title: "t", Message: "m"}, "templateID cannot be empty"},
+		{"invalid version", Template{ID: "t", Title: "t", Message: "m"}, "version must be positive"},
+		{"parse error", Template{ID: "t", Version: 1, Title: "{{.Broken", Message: "m"}, "failed to parse template"},
+		{"html outside email", Template{ID: "t", Version: 1, Title: "t", Message: "m", Variants: map[notification.Channel]Variant{
+			notification.ChannelSMS: {Body: "b", HTML: true},
+		}}, "only supported for the email channel"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := registry.Register(tt.template)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.contains)
+		})
+	}
+
+	_, err := registry.Render("missing", nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+}
+
+func TestRegistryRenderValidation(t *testing.T) {
+	registry := NewRegistry()
+	assert.NoError(t, registry.Register(orderShipped(1)))
+	assert.NoError(t, registry.Register(Template{
+		ID:       "long-sms",
+		Version:  1,
+		Title:    "Title",
+		Message:  "{{.Text}}",
+		Variants: map[notification.Channel]Variant{notification.ChannelSMS: {Body: "{{.Text}}"}},
+	}))
+
+	_, err := registry.Render("order-shipped", map[string]string{"Name": "Ann"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to render template")
+
+	_, err = registry.Render("long-sms", map[string]string{"Text": strings.Repeat("a", 2000)})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "sms body cannot exceed")
+
+	_, err = registry.Render("long-sms", map[string]string{"Text": ""})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "message cannot be empty")
+}
+
+func TestRegistryLocales(t *testing.T) {
+	registry := NewRegistry()
+	templates := []Template{
+		{ID: "files", Version: 1, Title: "Export ready", Message: `{{plural .Count "one" "# file" "other" "# files"}} exported`},
+		{ID: "files", Version: 2, Title: "Your export is ready", Message: `{{plural .Count "one" "# file" "other" "# files"}} exported`},
+		{ID: "files", Version: 1, Locale: "fr", Title: "Export prêt", Message: `{{plural .Count "one" "# fichier exporté" "other" "# fichiers exportés"}}`},
+		{ID: "files", Version: 1, Locale: "ru", Title: "Экспорт готов", Message: `{{plural .Count "one" "# файл" "few" "# файла" "many" "# файлов"}}`},
+	}
+	for _, tmpl := range templates {
+		assert.NoError(t, registry.Register(tmpl))
+	}
+
+	tests := []struct {
+		locale   string
+		count    int
+		resolved string
+		version  int
+		title    string
+		message  string
+	}{
+		{"fr-CA", 0, "fr", 1, "Export prêt", "0 fichier exporté"},
+		{"fr", 3, "fr", 1, "Export prêt", "3 fichiers exportés"},
+		{"ru-RU", 23, "ru", 1, "Экспорт готов", "23 файла"},
+		{"de-DE", 1, "en", 2, "Your export is ready", "1 file exported"},
+		{"", 0, "en", 2, "Your export is ready", "0 files exported"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			content, err := registry.RenderLocale("files", tt.locale, map[string]int{"Count": tt.count})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.resolved, content.Locale)
+			assert.Equal(t, tt.version, content.Version)
+			assert.Equal(t, tt.title, content.Title)
+			assert.Equal(t, tt.message, content.Message)
+		})
+	}
+
+	content, err := registry.RenderVersionLocale("files", 1, "fr-CA", map[string]int{"Count": 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "fr", content.Locale)
+
+	_, err = registry.RenderVersionLocale("files", 2, "fr-CA", map[string]int{"Count": 2})
+	assert.NoError(t, err)
+
+	_, err = registry.RenderVersionLocale("files", 3, "fr-CA", map[string]int{"Count": 2})
+	assert.Error(t, err)
+}
@@ -1,6 +1,10 @@
 package notification
 
-import "time"
+import (
+	"context"
+	"strconv"
+	"time"
+)
 
 // Error codes
 const (
@@ -43,17 +47,294 @@ const (
 	TypeSystem  NotificationType = 4
 )
 
+// notificationTypeNames maps notification types to their names
+var notificationTypeNames = map[NotificationType]string{
+	TypeInfo:    "info",
+	TypeWarning: "warning",
+	TypeError:   "error",
+	TypeSuccess: "success",
+	TypeSystem:  "system",
+}
+
+// String returns the name of a notification type, or its number for unknown types
+func (t NotificationType) String() string {
+	if name, ok := notificationTypeNames[t]; ok {
+		return name
+	}
+	return strconv.Itoa(int(t))
+}
+
+// ParseNotificationType parses a notification type from its name or number
+func ParseNotificationType(s string) (NotificationType, error) {
+	for t, name := range notificationTypeNames {
+		if name == s {
+			return t, nil
+		}
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		return NotificationType(n), nil
+	}
+	return 0, NewError(InvalidArguments, "unknown notification type: "+s)
+}
+
 // Notification represents a message sent to a user
 type Notification struct {
-	ID        string           `json:"id"`
-	ClientID  string           `json:"client_id"`
-	UserID    string           `json:"user_id"`
-	Title     string           `json:"title"`
-	Message   string           `json:"message"`
-	Type      NotificationType `json:"type"`
-	Read      bool             `json:"read"`
-	CreatedAt time.Time        `json:"created_at"`
-	Source    string           `json:"source"`
+	ID          string            `json:"id"`
+	ClientID    string            `json:"client_id"`
+	UserID      string            `json:"user_id"`
+	Title       string            `json:"title"`
+	Message     string            `json:"message"`
+	Type        NotificationType  `json:"type"`
+	Read        bool              `json:"read"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Source      string            `json:"source"`
+	Locale      string            `json:"locale,omitempty"`
+	Format      ContentFormat     `json:"format,omitempty"`
+	Actions     []Action          `json:"actions,omitempty"`
+	DeepLink    string            `json:"deep_link,omitempty"`
+	ImageURL    string            `json:"image_url,omitempty"`
+	IconURL     string            `json:"icon_url,omitempty"`
+	Attachments []Attachment      `json:"attachments,omitempty"`
+	Audience    *Audience         `json:"audience,omitempty"`
+	Channels    []Channel         `json:"channels,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
+
+	TemplateID      string                     `json:"template_id,omitempty"`
+	TemplateVersion int                        `json:"template_version,omitempty"`
+	Variants        map[Channel]ChannelContent `json:"variants,omitempty"`
+}
+
+// ContentFormat defines how the message of a notification is formatted
+type ContentFormat string
+
+// Content formats; an empty format is treated as plain text
+const (
+	FormatPlain    ContentFormat = "plain"
+	FormatMarkdown ContentFormat = "markdown"
+)
+
+// Action is a button shown with a notification
+type Action struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	URL   string `json:"url,omitempty"`
+}
+
+// Attachment references a file stored outside the notification, in an object store bucket
+type Attachment struct {
+	Name        string `json:"name"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+	Digest      string `json:"digest"`
+	Bucket      string `json:"bucket"`
+	Key         string `json:"key"`
+}
+
+// AudienceKind defines how the recipients of an audience are selected
+type AudienceKind string
+
+// Audience kinds
+const (
+	AudienceOrganization AudienceKind = "organization"
+	AudienceRole         AudienceKind = "role"
+	AudienceUsers        AudienceKind = "users"
+	AudienceSegment      AudienceKind = "segment"
+)
+
+// Audience targets a group of recipients instead of a single client
+type Audience struct {
+	Kind    AudienceKind `json:"kind"`
+	OrgID   string       `json:"org_id,omitempty"`
+	Role    string       `json:"role,omitempty"`
+	UserIDs []string     `json:"user_ids,omitempty"`
+	Segment string       `json:"segment,omitempty"`
+}
+
+// Recipient is a single user an audience expands to
+type Recipient struct {
+	ClientID string `json:"client_id"`
+	UserID   string `json:"user_id"`
+}
+
+// TopicSubscription records a user following a topic such as "project:123"
+type TopicSubscription struct {
+	Topic      string    `json:"topic"`
+	UserID     string    `json:"user_id"`
+	ClientID   string    `json:"client_id"`
+	Muted      bool      `json:"muted"`
+	MutedUntil time.Time `json:"muted_until,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// IsMuted reports whether the subscription is muted at the given time. A mute without
+// MutedUntil lasts until it is lifted.
+func (s *TopicSubscription) IsMuted(now time.Time) bool {
+	return s.Muted && (s.MutedUntil.IsZero() || now.Before(s.MutedUntil))
+}
+
+// Tenant is an organization hosted on shared infrastructure whose notifications must be
+// isolated from every other tenant
+type Tenant struct {
+	ID string `json:"id"`
+	// SubjectPrefix namespaces the tenant's subjects; empty derives one from the tenant ID
+	SubjectPrefix string `json:"subject_prefix,omitempty"`
+	// CredentialsFile is the NATS .creds file of a user in the tenant's account; empty
+	// connects with the publisher's default credentials
+	CredentialsFile string `json:"credentials_file,omitempty"`
+}
+
+// Principal is the identity a publisher acts as. Authorizers check notifications against
+// the principal the publisher was bound to, since callers set Notification.Source freely.
+type Principal struct {
+	// Source is the only source the principal may publish as
+	Source string `json:"source"`
+	// TenantID is the tenant (organization) the principal belongs to; optional
+	TenantID string `json:"tenant_id,omitempty"`
+}
+
+type principalKey struct{}
+
+// ContextWithPrincipal returns a context carrying the principal a notification is published as
+func ContextWithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal stored by ContextWithPrincipal
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+type broadcastKey struct{}
+
+// ContextWithOrgBroadcast returns a context marking the notification being authorized as
+// a broadcast to every user of an organization. Only the publisher's broadcast path sets
+// it; Notification.Audience is caller data and does not make a notification a broadcast.
+func ContextWithOrgBroadcast(ctx context.Context, orgID string) context.Context {
+	return context.WithValue(ctx, broadcastKey{}, orgID)
+}
+
+// OrgBroadcastFromContext returns the organization stored by ContextWithOrgBroadcast
+func OrgBroadcastFromContext(ctx context.Context) (string, bool) {
+	orgID, ok := ctx.Value(broadcastKey{}).(string)
+	return orgID, ok
+}
+
+// ActionResponse is published when a user responds to an action of a notification
+type ActionResponse struct {
+	NotificationID string    `json:"notification_id"`
+	ActionID       string    `json:"action_id"`
+	UserID         string    `json:"user_id"`
+	ClientID       string    `json:"client_id,omitempty"`
+	Source         string    `json:"source"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// Channel identifies a delivery channel for a notification
+type Channel string
+
+// Delivery channels
+const (
+	ChannelInApp   Channel = "in_app"
+	ChannelEmail   Channel = "email"
+	ChannelWebhook Channel = "webhook"
+	ChannelPush    Channel = "push"
+	ChannelSMS     Channel = "sms"
+)
+
+// Well-known notification metadata keys
+const (
+	MetadataOrgID      = "org_id"
+	MetadataWorkflowID = "workflow_id"
+	MetadataTopic      = "topic"
+)
+
+// DeliveryOutcome records the result of delivering a notification on a single channel
+type DeliveryOutcome struct {
+	NotificationID string        `json:"notification_id"`
+	Channel        Channel       `json:"channel"`
+	Delivered      bool          `json:"delivered"`
+	Error          string        `json:"error,omitempty"`
+	Duration       time.Duration `json:"duration"`
+	AttemptedAt    time.Time     `json:"attempted_at"`
+}
+
+// ChannelContent is the content of a notification rendered for a specific channel
+type ChannelContent struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body"`
+	HTML  bool   `json:"html,omitempty"`
+}
+
+// RenderedContent is the result of rendering a notification template
+type RenderedContent struct {
+	TemplateID string
+	Version    int
+	Locale     string
+	Type       NotificationType
+	Source     string
+	Title      string
+	Message    string
+	Variants   map[Channel]ChannelContent
+}
+
+// DeliveryStatus is a step in the delivery lifecycle of a notification
+type DeliveryStatus string
+
+// Delivery statuses
+const (
+	StatusQueued    DeliveryStatus = "queued"
+	StatusPublished DeliveryStatus = "published"
+	StatusDelivered DeliveryStatus = "delivered"
+	StatusRead      DeliveryStatus = "read"
+	StatusFailed    DeliveryStatus = "failed"
+)
+
+// StatusEvent reports a delivery status change of a notification, optionally on a single channel
+type StatusEvent struct {
+	NotificationID string         `json:"notification_id"`
+	ClientID       string         `json:"client_id,omitempty"`
+	Channel        Channel        `json:"channel,omitempty"`
+	Status         DeliveryStatus `json:"status"`
+	Error          string         `json:"error,omitempty"`
+	Reporter       string         `json:"reporter,omitempty"`
+	Timestamp      time.Time      `json:"timestamp"`
+}
+
+// HealthStatus is the result of a health check
+type HealthStatus string
+
+// Health statuses, from best to worst
+const (
+	HealthUp HealthStatus = "up"
+	// HealthDegraded components still work but need attention, e.g. a filling buffer
+	HealthDegraded HealthStatus = "degraded"
+	HealthDown     HealthStatus = "down"
+)
+
+// healthRanks orders health statuses from best to worst
+var healthRanks = map[HealthStatus]int{HealthUp: 0, HealthDegraded: 1, HealthDown: 2}
+
+// Worse returns the worse of two health statuses; unknown statuses count as down
+func (s HealthStatus) Worse(other HealthStatus) HealthStatus {
+	rank := func(status HealthStatus) int {
+		if r, ok := healthRanks[status]; ok {
+			return r
+		}
+		return healthRanks[HealthDown]
+	}
+	if rank(other) > rank(s) {
+		return other
+	}
+	return s
+}
+
+// HealthReport is the outcome of checking a single component
+type HealthReport struct {
+	Name    string         `json:"name"`
+	Status  HealthStatus   `json:"status"`
+	Error   string         `json:"error,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
 }
 
 // NotificationEvent represents a notification with an event ID for SSE